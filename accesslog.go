@@ -0,0 +1,84 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"time"
+)
+
+// accessLogEntry is one structured access log line
+type accessLogEntry struct {
+	Time       string `json:"time"`
+	RequestID  string `json:"request_id"`
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	Status     int    `json:"status"`
+	DurationMs int64  `json:"duration_ms"`
+	Results    *int   `json:"results,omitempty"`
+}
+
+// accessLogWriter records the status and result count of a response
+type accessLogWriter struct {
+	http.ResponseWriter
+	status  int
+	results *int
+}
+
+func (w *accessLogWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *accessLogWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// SetResultCount implements stream.ResultCounter
+func (w *accessLogWriter) SetResultCount(n int) {
+	w.results = &n
+}
+
+// serveWithAccessLog serves the request through next and writes one JSON line describing it
+func serveWithAccessLog(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	start := time.Now()
+
+	requestID := r.Header.Get("X-Request-ID")
+	if requestID == "" {
+		requestID = newRequestID()
+	}
+	w.Header().Set("X-Request-ID", requestID)
+
+	recorder := &accessLogWriter{ResponseWriter: w}
+	next.ServeHTTP(recorder, r)
+
+	if recorder.status == 0 {
+		recorder.status = http.StatusOK
+	}
+
+	json.NewEncoder(os.Stdout).Encode(accessLogEntry{
+		Time:       start.UTC().Format(time.RFC3339Nano),
+		RequestID:  requestID,
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		Status:     recorder.status,
+		DurationMs: time.Since(start).Milliseconds(),
+		Results:    recorder.results,
+	})
+}
+
+// newRequestID returns a random 16 character hex request ID
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
@@ -2,10 +2,12 @@ package caching
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
 	"stremfy/metadata"
+	"stremfy/scrapers"
 	"stremfy/stream"
 	"stremfy/types"
 	"sync"
@@ -22,28 +24,91 @@ type BackgroundTask struct {
 	Priority     int // 0 = user-triggered (high), 1 = trending (low)
 }
 
+// seasonPackTTL is how long a known cached season pack is reused before searching again
+const seasonPackTTL = 24 * time.Hour
+
+// SeasonPackCacheKey is the cache key holding the cached packs known for a series season
+func SeasonPackCacheKey(imdbID string, season int) string {
+	return fmt.Sprintf("season_packs_%s_%d", imdbID, season)
+}
+
+// BackgroundConfig holds configuration for the background worker
+type BackgroundConfig struct {
+	// UserDedupWindow is how long a user-triggered prefetch is not repeated (default 24 hours)
+	UserDedupWindow time.Duration
+	// TrendingDedupWindow is how long a trending prefetch is not repeated (default 24 hours)
+	TrendingDedupWindow time.Duration
+	// PacksFirst searches series packs first and skips season searches when cached packs cover every season
+	PacksFirst bool
+	// PacksOnly prefetches only season and complete-series packs of series, skipping single episodes
+	PacksOnly bool
+	// Concurrency is the maximum number of concurrent prefetch searches per title (default 5)
+	Concurrency int
+	// MaxSearches caps the concurrent prefetch searches across all tasks and workers (default 5)
+	MaxSearches int
+	// MovieQualities are extra movie queries, e.g. "1080p" searches "<title> <year> 1080p"
+	MovieQualities []string
+	// Trending prefetches trending titles periodically, starting at boot
+	Trending bool
+	// UserPrefetch prefetches the rest of a series when one of its episodes is requested
+	UserPrefetch bool
+	// TrendingMinAge skips trending titles released more recently, which have no good torrents yet (0 disables)
+	TrendingMinAge time.Duration
+}
+
+// cacheCheckBatchSize is the number of hashes sent per TorBox cache check
+const cacheCheckBatchSize = 100
+
 type BackgroundWork struct {
 	backgroundQueue  chan BackgroundTask
+	priorityQueue    chan BackgroundTask // user-triggered tasks, always taken first
 	bgWorkers        int
 	taskDeduplicator *TaskDeduplicator
 	searchTorrents   types.SearchFunc
+	checkCache       types.CheckCacheFunc
+	cache            types.Cache
 	metadataProvider *metadata.Provider
+	config           BackgroundConfig
 	stopChan         chan struct{}
 	workersDone      sync.WaitGroup
+	readyMu          sync.Mutex
+	searchSlots      chan struct{} // global limit on concurrent prefetch searches
 }
 
-func NewBackgroundWorker(searchFunc types.SearchFunc, provider *metadata.Provider) *BackgroundWork {
+func NewBackgroundWorker(searchFunc types.SearchFunc, checkCache types.CheckCacheFunc, cache types.Cache, provider *metadata.Provider, config BackgroundConfig) *BackgroundWork {
+	if config.UserDedupWindow <= 0 {
+		config.UserDedupWindow = 24 * time.Hour
+	}
+	if config.TrendingDedupWindow <= 0 {
+		config.TrendingDedupWindow = 24 * time.Hour
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = 5
+	}
+	if config.MaxSearches <= 0 {
+		config.MaxSearches = 5
+	}
+
 	bk := &BackgroundWork{
 		backgroundQueue:  make(chan BackgroundTask, 50),
+		priorityQueue:    make(chan BackgroundTask, 20),
 		bgWorkers:        1,
-		taskDeduplicator: NewTaskDeduplicator(),
+		taskDeduplicator: NewTaskDeduplicator(max(config.UserDedupWindow, config.TrendingDedupWindow)),
 		searchTorrents:   searchFunc,
+		checkCache:       checkCache,
+		cache:            cache,
 		metadataProvider: provider,
+		config:           config,
 		stopChan:         make(chan struct{}),
+		searchSlots:      make(chan struct{}, config.MaxSearches),
 	}
 
 	bk.startBackgroundWorkers()
-	bk.startTrending()
+	if config.Trending {
+		bk.startTrending()
+	} else {
+		log.Println("⏸️ Trending prefetch disabled")
+	}
 
 	return bk
 }
@@ -63,7 +128,8 @@ func (bk *BackgroundWork) Stop() {
 	// Signal all workers to stop
 	close(bk.stopChan)
 
-	// Close the queue (workers will finish current tasks)
+	// Close the queues (workers will finish current tasks)
+	close(bk.priorityQueue)
 	close(bk.backgroundQueue)
 
 	// Wait for all workers to finish with timeout
@@ -85,6 +151,7 @@ func (bk *BackgroundWork) Stop() {
 func (bk *BackgroundWork) StopAndWait() {
 	log.Println("🛑 Stopping background workers...")
 	close(bk.stopChan)
+	close(bk.priorityQueue)
 	close(bk.backgroundQueue)
 	bk.workersDone.Wait()
 	log.Println("✅ All background workers stopped")
@@ -94,11 +161,13 @@ func (bk *BackgroundWork) StopAndWait() {
 type TaskDeduplicator struct {
 	mu      sync.RWMutex
 	pending map[string]time.Time // IMDbID -> queued time
+	maxAge  time.Duration        // entries older than this are cleaned up
 }
 
-func NewTaskDeduplicator() *TaskDeduplicator {
+func NewTaskDeduplicator(maxAge time.Duration) *TaskDeduplicator {
 	td := &TaskDeduplicator{
 		pending: make(map[string]time.Time),
+		maxAge:  maxAge,
 	}
 
 	// Cleanup old entries every hour
@@ -122,6 +191,13 @@ func (td *TaskDeduplicator) ShouldQueue(id string, maxAge time.Duration) bool {
 	return true
 }
 
+// MarkQueued records id as queued now, whether or not it was queued before
+func (td *TaskDeduplicator) MarkQueued(id string) {
+	td.mu.Lock()
+	defer td.mu.Unlock()
+	td.pending[id] = time.Now()
+}
+
 func (td *TaskDeduplicator) Remove(imdbID string) {
 	td.mu.Lock()
 	defer td.mu.Unlock()
@@ -133,77 +209,113 @@ func (td *TaskDeduplicator) cleanupLoop() {
 	defer ticker.Stop()
 
 	for range ticker.C {
-		td.mu.Lock()
-		now := time.Now()
-		for imdbID, queuedAt := range td.pending {
-			// Remove entries older than 24 hours
-			if now.Sub(queuedAt) > 24*time.Hour {
-				delete(td.pending, imdbID)
-			}
+		td.cleanup()
+	}
+}
+
+// cleanup removes entries older than the longest dedup window
+func (td *TaskDeduplicator) cleanup() {
+	td.mu.Lock()
+	defer td.mu.Unlock()
+
+	now := time.Now()
+	for imdbID, queuedAt := range td.pending {
+		if now.Sub(queuedAt) > td.maxAge {
+			delete(td.pending, imdbID)
 		}
-		td.mu.Unlock()
 	}
 }
 
-// backgroundWorker processes tasks with priority
+// backgroundWorker processes queued tasks, user-triggered ones first
 func (bk *BackgroundWork) backgroundWorker(workerID int) {
 	defer bk.workersDone.Done()
 
 	log.Printf("🔧 [Worker %d] Started", workerID)
 
 	for {
-		select {
-		case task, ok := <-bk.backgroundQueue:
-			if !ok {
-				// Channel closed, exit
-				log.Printf("🛑 [Worker %d] Queue closed, exiting", workerID)
-				return
-			}
+		task, ok := bk.nextTask()
+		if !ok {
+			log.Printf("🛑 [Worker %d] Stop signal received, exiting", workerID)
+			return
+		}
 
-			log.Printf("🔄 [Worker %d] Starting %s: %s", workerID, task.Type, task.Title)
+		log.Printf("🔄 [Worker %d] Starting %s: %s", workerID, task.Type, task.Title)
 
-			switch task.Type {
-			case "series-prefetch":
-				bk.prefetchSeriesSeasons(task)
-			case "movie-prefetch":
-				bk.prefetchMovie(task)
-			case "trending-prefetch":
-				bk.prefetchTrendingContent()
-			}
+		switch task.Type {
+		case "series-prefetch":
+			bk.prefetchSeriesSeasons(task)
+		case "movie-prefetch":
+			bk.prefetchMovie(task)
+		case "trending-prefetch":
+			bk.prefetchTrendingContent()
+		}
+
+		// Mark task as completed
+		bk.taskDeduplicator.Remove(task.ID)
+
+		log.Printf("✅ [Worker %d] Completed: %s", workerID, task.Title)
+	}
+}
 
-			// Mark task as completed
-			bk.taskDeduplicator.Remove(task.ID)
+// nextTask blocks until a task is available, taking the priority queue before the
+// background one. It returns false once the worker should stop.
+func (bk *BackgroundWork) nextTask() (BackgroundTask, bool) {
+	select {
+	case task, ok := <-bk.priorityQueue:
+		return task, ok
+	default:
+	}
 
-			log.Printf("✅ [Worker %d] Completed: %s", workerID, task.Title)
+	select {
+	case task, ok := <-bk.priorityQueue:
+		return task, ok
+	case task, ok := <-bk.backgroundQueue:
+		return task, ok
+	case <-bk.stopChan:
+		return BackgroundTask{}, false
+	}
+}
 
-		case <-bk.stopChan:
-			// Stop signal received, exit gracefully
-			log.Printf("🛑 [Worker %d] Stop signal received, exiting", workerID)
-			return
-		}
+// enqueue adds a task to the queue matching its priority without blocking and returns
+// its position in that queue, or false when the queue is full
+func (bk *BackgroundWork) enqueue(task BackgroundTask) (int, bool) {
+	queue := bk.backgroundQueue
+	if task.Priority == 0 {
+		queue = bk.priorityQueue
+	}
+
+	select {
+	case queue <- task:
+		return len(queue), true
+	default:
+		return 0, false
 	}
 }
 
 func (bk *BackgroundWork) UserBackgroundTask(req stream.StreamRequest) {
+	if !bk.config.UserPrefetch {
+		return
+	}
+
 	// === BACKGROUND:  Queue prefetch task (non-blocking) ===
 	if req.IsSeries() {
 		metadata, err := bk.metadataProvider.GetMetadataFromTMDB(req.ID)
 		fullMetadata, err := bk.metadataProvider.GetTVShowDetails(metadata.ID)
 		if err == nil && metadata != nil {
-			// Check if already queued recently (within 24 hours)
-			if bk.taskDeduplicator.ShouldQueue(metadata.ID, 24*time.Hour) {
-				select {
-				case bk.backgroundQueue <- BackgroundTask{
+			// Check if already queued recently (within the user dedup window)
+			if bk.taskDeduplicator.ShouldQueue(metadata.ID, bk.config.UserDedupWindow) {
+				task := BackgroundTask{
 					Type:         "series-prefetch",
 					IMDbID:       req.ID,
 					ID:           metadata.ID,
 					Title:        fullMetadata.Name,
 					Year:         fullMetadata.Year,
 					TotalSeasons: fullMetadata.NumberOfSeasons,
-					Priority:     0, // High priority (user-triggered)
-				}:
+					Priority:     0,
+				}
+				if _, ok := bk.enqueue(task); ok {
 					log.Printf("📋 Queued background prefetch for %s", metadata.Title)
-				default:
+				} else {
 					log.Printf("⚠️ Background queue full")
 				}
 			} else {
@@ -213,6 +325,49 @@ func (bk *BackgroundWork) UserBackgroundTask(req stream.StreamRequest) {
 	}
 }
 
+// ErrQueueFull is returned when the background queue has no room for another task
+var ErrQueueFull = errors.New("background queue full")
+
+// PrefetchNow queues an explicitly requested prefetch of a title ahead of trending work,
+// ignoring the dedup window, and returns the queued task with its queue position
+func (bk *BackgroundWork) PrefetchNow(mediaType, imdbID string) (BackgroundTask, int, error) {
+	meta, err := bk.metadataProvider.GetMetadataFromTMDB(imdbID)
+	if err != nil {
+		return BackgroundTask{}, 0, fmt.Errorf("failed to get metadata for %s: %w", imdbID, err)
+	}
+	if meta.Type != mediaType {
+		return BackgroundTask{}, 0, fmt.Errorf("%s is a %s, not a %s", imdbID, meta.Type, mediaType)
+	}
+
+	task := BackgroundTask{
+		Type:     "movie-prefetch",
+		ID:       meta.ID,
+		IMDbID:   imdbID,
+		Title:    meta.Title,
+		Year:     meta.Year,
+		Priority: 0,
+	}
+	if mediaType == "series" {
+		details, err := bk.metadataProvider.GetTVShowDetails(meta.ID)
+		if err != nil {
+			return BackgroundTask{}, 0, fmt.Errorf("failed to get show details for %s: %w", imdbID, err)
+		}
+		task.Type = "series-prefetch"
+		task.TotalSeasons = details.NumberOfSeasons
+	}
+
+	position, ok := bk.enqueue(task)
+	if !ok {
+		return BackgroundTask{}, 0, ErrQueueFull
+	}
+
+	// Start the dedup window so the automatic prefetch doesn't repeat this one
+	bk.taskDeduplicator.MarkQueued(task.ID)
+
+	log.Printf("📋 Queued requested prefetch for %s (position %d)", task.Title, position)
+	return task, position, nil
+}
+
 // prefetchSeriesSeasons downloads hashes for all seasons/episodes
 func (bk *BackgroundWork) prefetchSeriesSeasons(task BackgroundTask) {
 	// Use a longer timeout for background tasks
@@ -222,21 +377,65 @@ func (bk *BackgroundWork) prefetchSeriesSeasons(task BackgroundTask) {
 	log.Printf("🎬 Prefetching all seasons for %s (%s)", task.Title, task.IMDbID)
 
 	// Search for complete series
-	queries := []string{
+	packQueries := []string{
 		fmt.Sprintf("%s complet", task.Title),
 		fmt.Sprintf("%s pack", task.Title),
 	}
 
 	// Also search season by season
+	var seasonQueries []string
 	for season := 1; season <= task.TotalSeasons; season++ {
-		queries = append(queries, fmt.Sprintf("%s S%02d", task.Title, season))
+		seasonQueries = append(seasonQueries, fmt.Sprintf("%s S%02d", task.Title, season))
 	}
 
 	var allHashes []string
+	var packs []types.ScrapeResult
+	var coveredSeasons int
+
+	if bk.config.PacksFirst {
+		// A cached complete pack covers every episode, so season searches are only needed without one
+		allHashes, packs = bk.runPrefetchQueries(ctx, task, packQueries)
+		coveredSeasons = bk.storeCachedSeasonPacks(task, packs)
+
+		if task.TotalSeasons > 0 && coveredSeasons >= task.TotalSeasons {
+			log.Printf("📦 Cached packs cover all %d seasons of %s, skipping %d season searches",
+				task.TotalSeasons, task.Title, len(seasonQueries))
+		} else {
+			seasonHashes, seasonPacks := bk.runPrefetchQueries(ctx, task, seasonQueries)
+			allHashes = append(allHashes, seasonHashes...)
+			packs = append(packs, seasonPacks...)
+			coveredSeasons = bk.storeCachedSeasonPacks(task, packs)
+		}
+	} else {
+		allHashes, packs = bk.runPrefetchQueries(ctx, task, append(packQueries, seasonQueries...))
+		coveredSeasons = bk.storeCachedSeasonPacks(task, packs)
+	}
+
+	// Deduplicate hashes
+	uniqueHashes := make(map[string]bool)
+	for _, hash := range allHashes {
+		uniqueHashes[hash] = true
+	}
+
+	if coveredSeasons > 0 {
+		bk.markSeriesReady(task)
+	}
+
+	log.Printf("✅ Prefetch complete for %s:  Downloaded and cached %d unique torrent hashes",
+		task.Title, len(uniqueHashes))
+}
+
+// runPrefetchQueries runs the prefetch searches concurrently and returns
+// the hashes found along with the season and complete-series packs among them
+func (bk *BackgroundWork) runPrefetchQueries(ctx context.Context, task BackgroundTask, queries []string) ([]string, []types.ScrapeResult) {
+	packsOnly := bk.config.PacksOnly && task.Type == "series-prefetch"
+
+	var allHashes []string
+	var packs []types.ScrapeResult
 	var mu sync.Mutex
 
 	var wg sync.WaitGroup
-	semaphore := make(chan struct{}, 5) // Max 5 concurrent searches
+	semaphore := make(chan struct{}, bk.config.Concurrency)
 
 	for _, query := range queries {
 		wg.Add(1)
@@ -248,12 +447,13 @@ func (bk *BackgroundWork) prefetchSeriesSeasons(task BackgroundTask) {
 			defer func() { <-semaphore }()
 
 			searchReq := types.ScrapeRequest{
-				Title:       query,
+				Title:       q,
 				MediaType:   "movie",
 				MediaOnlyID: task.IMDbID,
+				PacksOnly:   packsOnly,
 			}
 
-			torrents, err := bk.searchTorrents(ctx, searchReq)
+			torrents, err := bk.search(ctx, searchReq)
 			if err != nil {
 				log.Printf("⚠️ Background search failed for '%s': %v", q, err)
 				return
@@ -264,6 +464,9 @@ func (bk *BackgroundWork) prefetchSeriesSeasons(task BackgroundTask) {
 				if torrent.InfoHash != "" {
 					mu.Lock()
 					allHashes = append(allHashes, torrent.InfoHash)
+					if len(scrapers.PackSeasons(torrent.Title, task.TotalSeasons)) > 0 {
+						packs = append(packs, torrent)
+					}
 					mu.Unlock()
 				}
 			}
@@ -273,18 +476,126 @@ func (bk *BackgroundWork) prefetchSeriesSeasons(task BackgroundTask) {
 	}
 
 	wg.Wait()
+	return allHashes, packs
+}
 
-	// Deduplicate hashes
-	uniqueHashes := make(map[string]bool)
-	for _, hash := range allHashes {
-		uniqueHashes[hash] = true
+// search runs a prefetch search once a global search slot is free, so prefetching
+// never overwhelms Jackett however many tasks and workers run
+func (bk *BackgroundWork) search(ctx context.Context, req types.ScrapeRequest) ([]types.ScrapeResult, error) {
+	select {
+	case bk.searchSlots <- struct{}{}:
+		defer func() { <-bk.searchSlots }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
 	}
+	return bk.searchTorrents(ctx, req)
+}
 
-	log.Printf("✅ Prefetch complete for %s:  Downloaded and cached %d unique torrent hashes",
-		task.Title, len(uniqueHashes))
+// storeCachedSeasonPacks remembers which packs are cached on TorBox per season,
+// so episode requests can go straight to the pack instead of searching again.
+// It returns the number of seasons with a cached pack.
+func (bk *BackgroundWork) storeCachedSeasonPacks(task BackgroundTask, packs []types.ScrapeResult) int {
+	if bk.cache == nil || bk.checkCache == nil || task.IMDbID == "" || len(packs) == 0 {
+		return 0
+	}
+
+	packsByHash := make(map[string]types.ScrapeResult)
+	var hashes []string
+	for _, pack := range packs {
+		if _, exists := packsByHash[pack.InfoHash]; !exists {
+			packsByHash[pack.InfoHash] = pack
+			hashes = append(hashes, pack.InfoHash)
+		}
+	}
+
+	cachedHashes, err := bk.checkCacheBatched(hashes)
+	if err != nil {
+		log.Printf("⚠️ Failed to check cache for %d season packs of %s: %v", len(hashes), task.Title, err)
+		return 0
+	}
+
+	bySeason := make(map[int][]types.ScrapeResult)
+	for _, hash := range cachedHashes {
+		pack, exists := packsByHash[hash]
+		if !exists {
+			continue
+		}
+		for _, season := range scrapers.PackSeasons(pack.Title, task.TotalSeasons) {
+			bySeason[season] = append(bySeason[season], pack)
+		}
+	}
+
+	for season, seasonPacks := range bySeason {
+		bk.cache.Set(SeasonPackCacheKey(task.IMDbID, season), seasonPacks, seasonPackTTL)
+	}
+
+	log.Printf("💾 Stored cached packs for %d seasons of %s", len(bySeason), task.Title)
+	return len(bySeason)
 }
 
-// prefetchMovieVariants downloads hashes for different quality variants
+// markSeriesReady adds a prefetched series to the ready titles with its artwork
+func (bk *BackgroundWork) markSeriesReady(task BackgroundTask) {
+	title := ReadyTitle{
+		IMDbID: task.IMDbID,
+		Type:   "series",
+		Title:  task.Title,
+		Year:   task.Year,
+	}
+
+	if details, err := bk.metadataProvider.GetTVShowDetails(task.ID); err == nil {
+		title.Poster = metadata.ImageURL(details.PosterPath, "w500")
+		title.Background = metadata.ImageURL(details.BackdropPath, "original")
+	}
+
+	bk.markReady(title)
+}
+
+// markMovieReady adds a prefetched movie to the ready titles if any of its hashes is cached
+func (bk *BackgroundWork) markMovieReady(ctx context.Context, task BackgroundTask, hashes []string) {
+	if bk.checkCache == nil || task.IMDbID == "" || len(hashes) == 0 {
+		return
+	}
+
+	cachedHashes, err := bk.checkCacheBatched(hashes)
+	if err != nil {
+		log.Printf("⚠️ Failed to check cache for %s: %v", task.Title, err)
+		return
+	}
+	log.Printf("🔥 Warmed TorBox cache status for %d hashes of %s (%d cached)", len(hashes), task.Title, len(cachedHashes))
+	if len(cachedHashes) == 0 {
+		return
+	}
+
+	title := ReadyTitle{
+		IMDbID: task.IMDbID,
+		Type:   "movie",
+		Title:  task.Title,
+		Year:   task.Year,
+	}
+
+	if details, err := bk.metadataProvider.GetMovieDetails(ctx, task.ID); err == nil {
+		title.Poster = metadata.ImageURL(details.PosterPath, "w500")
+		title.Background = metadata.ImageURL(details.BackdropPath, "original")
+	}
+
+	bk.markReady(title)
+}
+
+// checkCacheBatched checks hashes against TorBox in batches, returning the cached ones
+func (bk *BackgroundWork) checkCacheBatched(hashes []string) ([]string, error) {
+	var cached []string
+	for start := 0; start < len(hashes); start += cacheCheckBatchSize {
+		end := min(start+cacheCheckBatchSize, len(hashes))
+		batch, err := bk.checkCache(hashes[start:end])
+		if err != nil {
+			return cached, err
+		}
+		cached = append(cached, batch...)
+	}
+	return cached, nil
+}
+
+// prefetchMovie downloads hashes for different quality variants
 func (bk *BackgroundWork) prefetchMovie(task BackgroundTask) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
 	defer cancel()
@@ -295,48 +606,24 @@ func (bk *BackgroundWork) prefetchMovie(task BackgroundTask) {
 	queries := []string{
 		fmt.Sprintf("%s %s", task.Title, task.Year),
 	}
-
-	var allHashes []string
-	var mu sync.Mutex
-	var wg sync.WaitGroup
-
-	for _, query := range queries {
-		wg.Add(1)
-		go func(q string) {
-			defer wg.Done()
-
-			searchReq := types.ScrapeRequest{
-				Title:       q,
-				MediaType:   "movie",
-				MediaOnlyID: task.IMDbID,
-			}
-
-			torrents, err := bk.searchTorrents(ctx, searchReq)
-			if err != nil {
-				log.Printf("⚠️ Background search failed for '%s':  %v", q, err)
-				return
-			}
-
-			for _, torrent := range torrents {
-				if torrent.InfoHash != "" {
-					mu.Lock()
-					allHashes = append(allHashes, torrent.InfoHash)
-					mu.Unlock()
-				}
-			}
-
-			log.Printf("📦 Background: Found %d torrents for '%s'", len(torrents), q)
-		}(query)
+	for _, quality := range bk.config.MovieQualities {
+		queries = append(queries, fmt.Sprintf("%s %s %s", task.Title, task.Year, quality))
 	}
 
-	wg.Wait()
+	allHashes, _ := bk.runPrefetchQueries(ctx, task, queries)
 
 	// Deduplicate
 	uniqueHashes := make(map[string]bool)
+	var hashes []string
 	for _, hash := range allHashes {
-		uniqueHashes[hash] = true
+		if !uniqueHashes[hash] {
+			uniqueHashes[hash] = true
+			hashes = append(hashes, hash)
+		}
 	}
 
+	bk.markMovieReady(ctx, task, hashes)
+
 	log.Printf("✅ Prefetch complete for %s:  Downloaded and cached %d unique torrent hashes",
 		task.Title, len(uniqueHashes))
 }
@@ -357,6 +644,16 @@ func (bk *BackgroundWork) startTrending() {
 	}()
 }
 
+// releasedBefore reports whether a TMDB date (YYYY-MM-DD) is before cutoff;
+// unknown dates count as unreleased, e.g. shows with no aired episode
+func releasedBefore(date string, cutoff time.Time) bool {
+	released, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return false
+	}
+	return released.Before(cutoff)
+}
+
 func (bk *BackgroundWork) prefetchTrendingContent() {
 
 	log.Println("📊 Checking for trending content to prefetch...")
@@ -398,10 +695,19 @@ func (bk *BackgroundWork) prefetchTrendingContent() {
 
 	// Queue prefetch tasks for each trending item
 	queued := 0
+	cutoff := time.Now().Add(-bk.config.TrendingMinAge)
 	for _, item := range allTrending {
+		name, released := item.Title, item.ReleaseDate
+		if item.MediaType == "tv" {
+			name, released = item.Name, item.FirstAirDate
+		}
+		if bk.config.TrendingMinAge > 0 && !releasedBefore(released, cutoff) {
+			log.Printf("⏭️ Skipping %s (released %q, too recent)", name, released)
+			continue
+		}
 
-		// Check deduplication (24 hours for trending)
-		if !bk.taskDeduplicator.ShouldQueue(strconv.Itoa(item.ID), 24*time.Hour) {
+		// Check deduplication (trending dedup window)
+		if !bk.taskDeduplicator.ShouldQueue(strconv.Itoa(item.ID), bk.config.TrendingDedupWindow) {
 			log.Printf("⏭️ Skipping %s (already prefetched)", item.Title)
 			continue
 		}
@@ -429,7 +735,7 @@ func (bk *BackgroundWork) prefetchTrendingContent() {
 			IMDbID:   imdbID,
 			Title:    item.Title,
 			Year:     year,
-			Priority: 1, // Low priority (trending)
+			Priority: 1,
 		}
 
 		if item.MediaType == "tv" {
@@ -439,15 +745,13 @@ func (bk *BackgroundWork) prefetchTrendingContent() {
 			task.Type = "movie-prefetch"
 		}
 
-		select {
-		case bk.backgroundQueue <- task:
+		if _, ok := bk.enqueue(task); ok {
 			queued++
 			log.Printf("📋 Queued trending prefetch [%d/%d]: %s", queued, len(allTrending), task.Title)
 
 			// Small delay to avoid overwhelming the system
 			time.Sleep(2 * time.Second)
-
-		default:
+		} else {
 			log.Printf("⚠️ Queue full, stopping trending prefetch at %d items", queued)
 			return
 		}
@@ -464,10 +768,10 @@ func (bk *BackgroundWork) prefetchTrendingContent() {
 
 // GetQueueSize returns current queue size for monitoring
 func (bk *BackgroundWork) GetQueueSize() int {
-	return len(bk.backgroundQueue)
+	return len(bk.priorityQueue) + len(bk.backgroundQueue)
 }
 
 // GetQueueCapacity returns queue capacity
 func (bk *BackgroundWork) GetQueueCapacity() int {
-	return cap(bk.backgroundQueue)
+	return cap(bk.priorityQueue) + cap(bk.backgroundQueue)
 }
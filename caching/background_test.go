@@ -0,0 +1,100 @@
+package caching
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTaskDeduplicatorMarkQueued(t *testing.T) {
+	td := NewTaskDeduplicator(time.Hour)
+
+	td.MarkQueued("1399")
+	if td.ShouldQueue("1399", time.Hour) {
+		t.Error("ShouldQueue right after MarkQueued = true, want false within the window")
+	}
+	if !td.ShouldQueue("1399", 0) {
+		t.Error("ShouldQueue with no window = false, want true")
+	}
+
+	// Marking an already queued title restarts its window
+	td.pending["1400"] = time.Now().Add(-2 * time.Hour)
+	td.MarkQueued("1400")
+	if td.ShouldQueue("1400", time.Hour) {
+		t.Error("ShouldQueue after re-marking = true, want false within the window")
+	}
+
+	td.Remove("1400")
+	if !td.ShouldQueue("1400", time.Hour) {
+		t.Error("ShouldQueue after Remove = false, want true")
+	}
+}
+
+func TestTaskDeduplicatorWindows(t *testing.T) {
+	userWindow, trendingWindow := 2*time.Hour, 24*time.Hour
+	td := NewTaskDeduplicator(trendingWindow)
+
+	// Queued 3 hours ago: past the user window, still inside the trending one
+	td.pending["1399"] = time.Now().Add(-3 * time.Hour)
+	if td.ShouldQueue("1399", trendingWindow) {
+		t.Error("ShouldQueue inside the trending window = true, want false")
+	}
+
+	td.pending["1399"] = time.Now().Add(-3 * time.Hour)
+	if !td.ShouldQueue("1399", userWindow) {
+		t.Error("ShouldQueue past the user window = false, want true")
+	}
+	// Queuing restarts the window
+	if td.ShouldQueue("1399", userWindow) {
+		t.Error("ShouldQueue right after queuing = true, want false")
+	}
+
+	// Cleanup only forgets entries older than the longest window
+	td.pending["old"] = time.Now().Add(-25 * time.Hour)
+	td.pending["recent"] = time.Now().Add(-23 * time.Hour)
+	td.cleanup()
+	if _, ok := td.pending["old"]; ok {
+		t.Error("cleanup kept an entry older than the longest window")
+	}
+	if _, ok := td.pending["recent"]; !ok {
+		t.Error("cleanup dropped an entry inside the longest window")
+	}
+}
+
+func TestPriorityTasksRunFirst(t *testing.T) {
+	bk := &BackgroundWork{
+		backgroundQueue: make(chan BackgroundTask, 5),
+		priorityQueue:   make(chan BackgroundTask, 5),
+		stopChan:        make(chan struct{}),
+	}
+
+	for _, title := range []string{"Trending 1", "Trending 2"} {
+		if _, ok := bk.enqueue(BackgroundTask{Title: title, Priority: 1}); !ok {
+			t.Fatalf("enqueue(%q) failed", title)
+		}
+	}
+	for i, title := range []string{"Requested 1", "Requested 2"} {
+		position, ok := bk.enqueue(BackgroundTask{Title: title, Priority: 0})
+		if !ok {
+			t.Fatalf("enqueue(%q) failed", title)
+		}
+		if position != i+1 {
+			t.Errorf("enqueue(%q) position = %d, want %d", title, position, i+1)
+		}
+	}
+	if got := bk.GetQueueSize(); got != 4 {
+		t.Errorf("GetQueueSize() = %d, want 4", got)
+	}
+
+	want := []string{"Requested 1", "Requested 2", "Trending 1", "Trending 2"}
+	for _, title := range want {
+		task, ok := bk.nextTask()
+		if !ok || task.Title != title {
+			t.Fatalf("nextTask() = %q, %v, want %q", task.Title, ok, title)
+		}
+	}
+
+	close(bk.stopChan)
+	if _, ok := bk.nextTask(); ok {
+		t.Error("nextTask() after stop = true, want false")
+	}
+}
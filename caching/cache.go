@@ -1,7 +1,9 @@
 package caching
 
 import (
+	"bytes"
 	"encoding/gob"
+	"fmt"
 	"log"
 	"os"
 	"sync"
@@ -17,20 +19,102 @@ type Item struct {
 
 // Cache is a generic thread-safe cache with TTL support
 type Cache struct {
-	mu    sync.RWMutex
-	items map[string]*Item
-	dirty bool
+	mu         sync.RWMutex
+	items      map[string]*Item
+	dirty      bool
+	memoryOnly bool
+
+	stopChan  chan struct{}
+	stopOnce  sync.Once
+	loopsDone sync.WaitGroup
 }
 
-// cacheData is used for serialization (gob can't encode mutexes)
+// Config holds configuration for the cache
+type Config struct {
+	// CleanupInterval is how often expired items are removed (default 5 minutes)
+	CleanupInterval time.Duration
+	// SaveInterval is how often dirty caches are written to disk (default 30 seconds)
+	SaveInterval time.Duration
+	// MemoryOnly never reads or writes the cache file
+	MemoryOnly bool
+}
+
+// Register the generic types stored as interface{} in the cache, so the cache file decodes.
+// Packages register their own cached types in their init.
+func init() {
+	gob.Register(map[string]interface{}{})
+	gob.Register([]interface{}{})
+	gob.Register([]string{})
+	gob.Register(time.Time{})
+	gob.Register([]ReadyTitle{})
+}
+
+// cacheVersion is the schema version of the cache file. Bump it when a cached type
+// changes shape; entries that no longer decode are dropped one by one on load.
+const cacheVersion = 2
+
+// cacheData is used for serialization (gob can't encode mutexes). Each value is encoded
+// on its own so a value that no longer decodes doesn't discard the whole file.
 type cacheData struct {
+	Version int
+	Items   map[string]*storedItem
+}
+
+// storedItem is an Item with its value gob-encoded separately
+type storedItem struct {
+	Value        []byte
+	ExpiresAt    time.Time
+	NeverExpires bool
+}
+
+// legacyCacheData is the unversioned cache file, decoded all at once
+type legacyCacheData struct {
 	Items map[string]*Item
 }
 
+// valueBox lets gob encode the dynamic type of an interface value
+type valueBox struct {
+	Value interface{}
+}
+
+func encodeValue(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(valueBox{Value: value}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeValue(data []byte) (interface{}, error) {
+	var box valueBox
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&box); err != nil {
+		return nil, err
+	}
+	return box.Value, nil
+}
+
 // NewCache creates a new cache instance
-func NewCache() *Cache {
+func NewCache(config Config) *Cache {
+	if config.CleanupInterval <= 0 {
+		config.CleanupInterval = 5 * time.Minute
+	}
+	if config.SaveInterval <= 0 {
+		config.SaveInterval = 30 * time.Second
+	}
+
 	c := &Cache{
-		items: make(map[string]*Item),
+		items:      make(map[string]*Item),
+		memoryOnly: config.MemoryOnly,
+		stopChan:   make(chan struct{}),
+	}
+
+	// Start periodic cleanup
+	c.loopsDone.Add(1)
+	go c.startCleanup(config.CleanupInterval)
+
+	if c.memoryOnly {
+		log.Println("🧠 Cache running in memory-only mode")
+		return c
 	}
 
 	// Try to load existing cache from file
@@ -40,9 +124,8 @@ func NewCache() *Cache {
 		log.Printf("✅ Loaded cache from file: %d entries", len(c.items))
 	}
 
-	// Start periodic cleanup
-	go c.startCleanup(5 * time.Minute)
-	go c.startPeriodicSave(30 * time.Second)
+	c.loopsDone.Add(1)
+	go c.startPeriodicSave(config.SaveInterval)
 
 	return c
 }
@@ -127,11 +210,18 @@ func (c *Cache) Size() int {
 
 // startCleanup starts a goroutine that periodically removes expired items
 func (c *Cache) startCleanup(interval time.Duration) {
+	defer c.loopsDone.Done()
+
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		c.cleanup()
+	for {
+		select {
+		case <-ticker.C:
+			c.cleanup()
+		case <-c.stopChan:
+			return
+		}
 	}
 }
 
@@ -185,10 +275,18 @@ func (c *Cache) GetStats() map[string]interface{} {
 }
 
 func (c *Cache) startPeriodicSave(interval time.Duration) {
+	defer c.loopsDone.Done()
+
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	for range ticker.C {
+	for {
+		select {
+		case <-ticker.C:
+		case <-c.stopChan:
+			return
+		}
+
 		c.mu.Lock()
 		if c.dirty {
 			c.mu.Unlock()
@@ -218,11 +316,48 @@ func (c *Cache) loadFromFile() error {
 	defer file.Close()
 
 	var data cacheData
-	decoder := gob.NewDecoder(file)
-	if err := decoder.Decode(&data); err != nil {
+	if err := gob.NewDecoder(file).Decode(&data); err != nil || data.Version == 0 {
+		return c.loadLegacyFile()
+	}
+	if data.Version != cacheVersion {
+		log.Printf("🔄 Cache file version %d, current %d: dropping entries that no longer decode", data.Version, cacheVersion)
+	}
+
+	items := make(map[string]*Item, len(data.Items))
+	dropped := 0
+	for key, stored := range data.Items {
+		value, err := decodeValue(stored.Value)
+		if err != nil {
+			dropped++
+			continue
+		}
+		items[key] = &Item{Value: value, ExpiresAt: stored.ExpiresAt, NeverExpires: stored.NeverExpires}
+	}
+	if dropped > 0 {
+		log.Printf("🗑️ Dropped %d incompatible cache entries", dropped)
+	}
+
+	c.mu.Lock()
+	c.items = items
+	c.mu.Unlock()
+
+	return nil
+}
+
+// loadLegacyFile loads a cache file written before values were encoded one by one
+func (c *Cache) loadLegacyFile() error {
+	file, err := os.Open(".cache")
+	if err != nil {
 		return err
 	}
+	defer file.Close()
+
+	var data legacyCacheData
+	if err := gob.NewDecoder(file).Decode(&data); err != nil {
+		return fmt.Errorf("unreadable cache file: %w", err)
+	}
 
+	log.Printf("🔄 Loaded unversioned cache file, it's rewritten in version %d on the next save", cacheVersion)
 	c.mu.Lock()
 	c.items = data.Items
 	c.mu.Unlock()
@@ -234,7 +369,16 @@ func (c *Cache) loadFromFile() error {
 func (c *Cache) saveToFile() error {
 	c.mu.RLock()
 	data := cacheData{
-		Items: c.items,
+		Version: cacheVersion,
+		Items:   make(map[string]*storedItem, len(c.items)),
+	}
+	for key, item := range c.items {
+		value, err := encodeValue(item.Value)
+		if err != nil {
+			log.Printf("⚠️ Not saving cache entry %s: %v", key, err)
+			continue
+		}
+		data.Items[key] = &storedItem{Value: value, ExpiresAt: item.ExpiresAt, NeverExpires: item.NeverExpires}
 	}
 	c.mu.RUnlock()
 
@@ -256,6 +400,19 @@ func (c *Cache) saveToFile() error {
 	return nil
 }
 
+// Close stops the cleanup and save goroutines and waits for them to exit.
+// It doesn't flush, call Flush first to persist pending changes.
+func (c *Cache) Close() {
+	c.stopOnce.Do(func() {
+		close(c.stopChan)
+	})
+	c.loopsDone.Wait()
+}
+
+// Flush writes the cache to disk, it's a no-op in memory-only mode
 func (c *Cache) Flush() error {
+	if c.memoryOnly {
+		return nil
+	}
 	return c.saveToFile()
 }
@@ -0,0 +1,118 @@
+package caching
+
+import (
+	"reflect"
+	"runtime"
+	"stremfy/debrid"
+	"stremfy/metadata"
+	"stremfy/scrapers"
+	"stremfy/types"
+	"testing"
+	"time"
+)
+
+// reloadCache flushes the cache to disk and loads a new cache from the file
+func reloadCache(t *testing.T, c *Cache) *Cache {
+	t.Helper()
+	if err := c.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	c.Close()
+
+	reloaded := NewCache(Config{})
+	t.Cleanup(reloaded.Close)
+	return reloaded
+}
+
+func TestCacheReloadKeepsTypes(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	c := NewCache(Config{})
+	seeders := 12
+	c.Set("results", []types.ScrapeResult{{Title: "Inception 2010", InfoHash: "abc", Seeders: &seeders}}, time.Hour)
+	c.SetPermanent("title", "Inception")
+	c.Set("expired", "gone", -time.Minute)
+
+	reloaded := reloadCache(t, c)
+
+	results, found := types.CacheGet[[]types.ScrapeResult](reloaded, "results")
+	if !found || len(results) != 1 || results[0].Title != "Inception 2010" || *results[0].Seeders != 12 {
+		t.Errorf("results = %+v (found %v), want the saved results", results, found)
+	}
+	if title, found := types.CacheGet[string](reloaded, "title"); !found || title != "Inception" {
+		t.Errorf("title = %q (found %v), want Inception", title, found)
+	}
+	if _, found := reloaded.Get("expired"); found {
+		t.Error("expired entry came back after reload")
+	}
+	// A typed read of the wrong type misses instead of panicking
+	if _, found := types.CacheGet[int](reloaded, "title"); found {
+		t.Error("string entry read back as an int")
+	}
+}
+
+func TestCacheReloadRegisteredTypes(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	seeders := 3
+	values := map[string]interface{}{
+		// caching
+		"map":          map[string]interface{}{"hash": "abc"},
+		"interfaces":   []interface{}{"a", "b"},
+		"strings":      []string{"udp://tracker.example:1337"},
+		"time":         time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		"ready titles": []ReadyTitle{{IMDbID: "tt1375666", Type: "movie", Title: "Inception"}},
+		// types
+		"scrape result":  types.ScrapeResult{Title: "Inception", InfoHash: "abc", Seeders: &seeders},
+		"scrape results": []types.ScrapeResult{{Title: "Inception", InfoHash: "abc"}},
+		// scrapers
+		"jackett result":  scrapers.JackettResult{Title: "Inception", Seeders: &seeders, Category: []int{2040}},
+		"jackett results": []scrapers.JackettResult{{Title: "Inception", Details: "https://t/1"}},
+		"cached hash":     scrapers.CachedHash{Hash: "abc", Sources: []string{"udp://tracker.example:1337"}},
+		// debrid
+		"cache checks": []debrid.CacheCheck{{Hash: "abc", Files: []debrid.CachedFileInfo{{Name: "movie.mkv", Size: 1 << 30, ID: 4}}}},
+		// metadata
+		"artwork": metadata.Artwork{Poster: "https://image.tmdb.org/t/p/w500/p.jpg"},
+	}
+
+	c := NewCache(Config{})
+	for key, value := range values {
+		c.SetPermanent(key, value)
+	}
+
+	reloaded := reloadCache(t, c)
+
+	for key, want := range values {
+		got, found := reloaded.Get(key)
+		if !found {
+			t.Errorf("%s: missing after reload", key)
+			continue
+		}
+		if reflect.TypeOf(got) != reflect.TypeOf(want) {
+			t.Errorf("%s: reloaded as %T, want %T", key, got, want)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: reloaded as %+v, want %+v", key, got, want)
+		}
+	}
+}
+
+func TestCacheCloseStopsGoroutines(t *testing.T) {
+	t.Chdir(t.TempDir())
+	before := runtime.NumGoroutine()
+
+	for _, memoryOnly := range []bool{true, false} {
+		c := NewCache(Config{MemoryOnly: memoryOnly, CleanupInterval: time.Millisecond, SaveInterval: time.Millisecond})
+		c.Set("key", "value", time.Hour)
+		time.Sleep(5 * time.Millisecond)
+		c.Close()
+		// Closing twice is a no-op
+		c.Close()
+	}
+
+	// Close waits for the loops, so they are gone without polling
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("%d goroutines after Close, want at most %d", after, before)
+	}
+}
@@ -0,0 +1,92 @@
+package caching
+
+import (
+	"log"
+	"sort"
+	"stremfy/types"
+	"time"
+)
+
+// ReadyTitle is a title the prefetcher found cached on TorBox
+type ReadyTitle struct {
+	IMDbID     string
+	Type       string // "movie" or "series"
+	Title      string
+	Year       string
+	Poster     string
+	Background string
+	PreparedAt time.Time
+}
+
+const (
+	// ReadyTitlesCacheKey is the cache key holding the ready titles
+	ReadyTitlesCacheKey = "ready_titles"
+
+	// readyTitleTTL is how long a title stays in the catalog after its last prefetch
+	readyTitleTTL = 7 * 24 * time.Hour
+
+	// maxReadyTitles caps the catalog size, oldest titles are dropped first
+	maxReadyTitles = 200
+)
+
+// markReady records a prefetched title as cached on TorBox
+func (bk *BackgroundWork) markReady(title ReadyTitle) {
+	if bk.cache == nil || title.IMDbID == "" {
+		return
+	}
+
+	bk.readyMu.Lock()
+	defer bk.readyMu.Unlock()
+
+	title.PreparedAt = time.Now()
+	titles := []ReadyTitle{title}
+	for _, existing := range bk.loadReadyTitles() {
+		if existing.IMDbID != title.IMDbID {
+			titles = append(titles, existing)
+		}
+	}
+	if len(titles) > maxReadyTitles {
+		titles = titles[:maxReadyTitles]
+	}
+
+	bk.cache.SetPermanent(ReadyTitlesCacheKey, titles)
+	log.Printf("🌟 %s is ready to stream (%d titles ready)", title.Title, len(titles))
+}
+
+// ReadyTitles returns the unexpired ready titles of a media type, most recently prepared first
+func (bk *BackgroundWork) ReadyTitles(mediaType string) []ReadyTitle {
+	bk.readyMu.Lock()
+	defer bk.readyMu.Unlock()
+
+	var titles []ReadyTitle
+	for _, title := range bk.loadReadyTitles() {
+		if title.Type == mediaType {
+			titles = append(titles, title)
+		}
+	}
+
+	sort.SliceStable(titles, func(i, j int) bool {
+		return titles[i].PreparedAt.After(titles[j].PreparedAt)
+	})
+	return titles
+}
+
+// loadReadyTitles reads the ready titles from the cache, dropping expired ones (caller holds readyMu)
+func (bk *BackgroundWork) loadReadyTitles() []ReadyTitle {
+	if bk.cache == nil {
+		return nil
+	}
+
+	stored, found := types.CacheGet[[]ReadyTitle](bk.cache, ReadyTitlesCacheKey)
+	if !found {
+		return nil
+	}
+
+	var titles []ReadyTitle
+	for _, title := range stored {
+		if time.Since(title.PreparedAt) < readyTitleTTL {
+			titles = append(titles, title)
+		}
+	}
+	return titles
+}
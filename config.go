@@ -0,0 +1,783 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"stremfy/scrapers"
+	"stremfy/torrentManager"
+	"stremfy/utils"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Debrid services selected by DEBRID_SERVICE
+const (
+	debridTorBox     = "torbox"
+	debridRealDebrid = "realdebrid"
+	debridAllDebrid  = "alldebrid"
+)
+
+// Config holds the addon configuration
+type Config struct {
+	// DebridService is the service streams go through: debridTorBox, debridRealDebrid or debridAllDebrid
+	DebridService    string
+	RealDebridAPIKey string
+	AllDebridAPIKey  string
+	TorBoxAPIKey     string
+	JackettURL       string
+	JackettAPIKey    string
+	// IndexerType is the search API of JackettURL: jackett, prowlarr or torznab
+	IndexerType string
+	// JackettInstances are more indexer servers searched alongside JackettURL
+	JackettInstances []scrapers.JackettInstance
+	TMDBAPIKey       string
+	Port             string
+
+	// API roots, overridable to go through a proxy or to run the pipeline against fake servers
+	TorBoxAPIURL     string
+	RealDebridAPIURL string
+	AllDebridAPIURL  string
+	TMDBAPIURL       string
+
+	// RootMode selects what "/" serves: RootJSON, RootRedirect or RootHTML
+	RootMode string
+
+	// LogFormat is "text" (default) or "json" for one structured access log line per request
+	LogFormat string
+
+	// EnablePprof serves runtime profiles on PprofAddr, a separate debug listener
+	EnablePprof bool
+	PprofAddr   string
+
+	// AdminToken authorizes expensive requests such as ?refresh=true (sent as X-Admin-Token)
+	AdminToken string
+
+	SearchTTL   time.Duration
+	MetadataTTL time.Duration
+	TorBoxTTL   time.Duration
+
+	// TorBoxUncachedTTL is how long a hash reported as not cached is skipped by cache checks
+	TorBoxUncachedTTL time.Duration
+
+	// CacheCleanupInterval and CacheSaveInterval tune how often the caches are purged and the cache persisted
+	CacheCleanupInterval time.Duration
+	CacheSaveInterval    time.Duration
+	// CacheMemoryOnly disables the on-disk cache file
+	CacheMemoryOnly bool
+
+	// CachedFirst sorts direct-URL streams above InfoHash streams regardless of size
+	CachedFirst bool
+
+	// StreamName labels the provider in stream names (default "TorBox", "Real-Debrid" or "AllDebrid"), InstanceTag is appended
+	// to tell several installs apart, e.g. "TorBox home"
+	StreamName  string
+	InstanceTag string
+
+	// Catalog tile shapes ("poster", "landscape" or "square") and the content type of the downloads catalog
+	ReadyPosterShape     string
+	DownloadsPosterShape string
+	DownloadsType        string
+
+	// P2PAlternates adds a P2P (InfoHash) stream after every direct-URL stream of the same file
+	P2PAlternates bool
+
+	// SlowRequestThreshold logs stream requests taking longer, with the time per upstream (0 disables)
+	SlowRequestThreshold time.Duration
+
+	// MaxActiveStreams sheds stream requests above this many in flight with an empty response (0 = unlimited)
+	MaxActiveStreams int
+
+	// BingeGroupLimit caps how many streams carry the same binge group (0 = unlimited)
+	BingeGroupLimit int
+
+	// SortMode is SortSize (default), SortInterleaved, which lists the best stream of every quality first,
+	// SortSeeders or SortQuality. Stream requests can override it with ?sort=
+	SortMode string
+
+	// PreferDualAudio sorts dual-audio (original + dub) releases above the others
+	PreferDualAudio bool
+
+	// SearchLocale picks the words of the series pack queries, SeriesPackWords overrides them
+	SearchLocale    string
+	SeriesPackWords []string
+	// StripReleaseNoise ignores release tags of torrent titles when matching them
+	StripReleaseNoise bool
+	// SearchOriginalTitle also searches and matches the original-language title
+	SearchOriginalTitle bool
+	// MovieQueryYear also searches movies as "<title> <year>"
+	MovieQueryYear bool
+	// UnpaddedQueries adds "S1" and "1x05" series searches besides "S01"
+	UnpaddedQueries bool
+
+	// PreferredCodec ranks encodes in this codec above other encodes of the same title and resolution
+	PreferredCodec string
+
+	// PlainTitles formats stream titles as text only, without emojis
+	PlainTitles bool
+
+	// SizeRanges are the plausible sizes per quality used to drop fake torrents
+	SizeRanges map[string]scrapers.SizeRange
+
+	// BlockedKeywords and BlockedGroups drop matching results before processing
+	BlockedKeywords []string
+	BlockedGroups   []string
+
+	// ShowReliability prepends a 🟢/🟡/🔴 tier computed from seeders and tracker to stream titles
+	ShowReliability        bool
+	ReliabilityHighSeeders int
+	ReliabilityLowSeeders  int
+	PreferredTrackers      []string
+
+	// TorrentDownloadTimeout and TorrentMaxBytes limit .torrent downloads from indexer links
+	TorrentDownloadTimeout time.Duration
+	TorrentMaxBytes        int64
+
+	// MaxScrapeResults caps the results processed per search
+	MaxScrapeResults int
+
+	// AllowAdult keeps adult results and flags the manifest as adult
+	AllowAdult bool
+
+	// TorBox HTTP transport tuning
+	TorBoxMaxIdleConns    int
+	TorBoxMaxConnsPerHost int
+	TorBoxDisableHTTP2    bool
+
+	// StreamTimeout bounds a whole stream request, SearchTimeout is the part of it the search may use.
+	// The upstream timeouts should stay below them, see the startup check in loadConfig
+	StreamTimeout  time.Duration
+	SearchTimeout  time.Duration
+	JackettTimeout time.Duration
+	DebridTimeout  time.Duration
+
+	// SearchEmptyRetryDelay retries a search that found nothing once after this delay (0 disables)
+	SearchEmptyRetryDelay time.Duration
+
+	// UncachedWait is how long to wait for the best torrent to download on TorBox when nothing is cached (0 disables)
+	UncachedWait time.Duration
+
+	// WebhookSecret enables POST /torbox/webhook, authenticated by this shared secret
+	WebhookSecret string
+
+	// LazyUnrestrict returns /resolve links on PublicURL instead of requesting every download link upfront
+	LazyUnrestrict bool
+	PublicURL      string
+	// ResolveSecret signs /resolve links, a random one per run when unset
+	ResolveSecret string
+	// ResolveLinkTTL is how long a signed /resolve link stays valid
+	ResolveLinkTTL time.Duration
+
+	// UnrestrictConcurrency bounds the download URLs requested in parallel per stream request
+	UnrestrictConcurrency int
+
+	// TorBoxRateLimit is the maximum TorBox requests per second (0 disables)
+	TorBoxRateLimit float64
+	TorBoxRateBurst int
+
+	// Prefetch dedup windows: how long the same title is not prefetched again, per task type
+	PrefetchUserDedupWindow     time.Duration
+	PrefetchTrendingDedupWindow time.Duration
+
+	// PrefetchPacksFirst skips per-season prefetch searches when cached packs already cover every season
+	PrefetchPacksFirst bool
+
+	// PrefetchPacksOnly prefetches only season/series packs of series, never single episodes
+	PrefetchPacksOnly bool
+
+	// PrefetchConcurrency bounds the concurrent prefetch searches per title
+	PrefetchConcurrency int
+
+	// PrefetchMaxSearches bounds the concurrent prefetch searches across all titles
+	PrefetchMaxSearches int
+
+	// PrefetchMovieQualities are extra quality-variant movie prefetch queries
+	PrefetchMovieQualities []string
+	// PrefetchTrending and PrefetchOnWatch enable the trending and user-triggered prefetches
+	PrefetchTrending bool
+	PrefetchOnWatch  bool
+	// PrefetchTrendingMinAge skips trending titles released within this many days
+	PrefetchTrendingMinAge time.Duration
+
+	// ReadyCatalog exposes the titles prefetch found cached on TorBox as a "Ready to Stream" catalog
+	ReadyCatalog bool
+
+	// DownloadsCatalog adds a catalog of in-progress TorBox downloads with their progress
+	DownloadsCatalog bool
+	// CloudCatalog adds a catalog of the finished TorBox downloads, filterable by quality
+	CloudCatalog bool
+
+	// SeriesMeta serves series metas with per-episode release dates, overviews and thumbnails from TMDB
+	SeriesMeta bool
+
+	// EpisodeLookahead pre-warms the links of this many following episodes of a cached season pack (at most 2)
+	EpisodeLookahead int
+
+	// EpisodeBestGuess offers the only video of a single-episode torrent when no file name matches the episode
+	EpisodeBestGuess bool
+
+	// AnimeAbsoluteEpisodes also searches and matches series episodes by their absolute number (S02E05 -> 29)
+	AnimeAbsoluteEpisodes bool
+
+	// MovieFileMode selects which video files of a movie torrent become streams
+	MovieFileMode    string
+	MovieFileMinSize int64
+
+	// VideoExtensions overrides the recognised video extensions ("+ext"/"-ext" adjust the defaults)
+	VideoExtensions []string
+
+	// ContainerExtensions are extra containers offered as streams, e.g. .iso or .m3u8 (default none)
+	ContainerExtensions []string
+
+	// FallbackTrackers are added to the sources of every P2P (InfoHash) stream
+	FallbackTrackers []string
+
+	// TrackersURL is a remote tracker list (one per line) replacing FallbackTrackers, refreshed every TrackersRefresh
+	TrackersURL     string
+	TrackersRefresh time.Duration
+
+	// P2PMinSeeders is the minimum seeders for offering a P2P (InfoHash) fallback stream
+	P2PMinSeeders int
+}
+
+// Movie file selection modes
+const (
+	MovieFilesLargest   = "largest"   // only the largest video file (main feature)
+	MovieFilesAll       = "all"       // every video file
+	MovieFilesThreshold = "threshold" // every video file of at least MovieFileMinSize
+)
+
+// Stream sort modes
+const (
+	SortSize        = "size"        // largest first
+	SortInterleaved = "interleaved" // best of every quality tier first, then largest first
+	SortSeeders     = "seeders"     // most seeded first, then largest first
+	SortQuality     = "quality"     // highest resolution first, then largest first
+)
+
+// validSortMode reports whether mode is one of the stream sort modes
+func validSortMode(mode string) bool {
+	switch mode {
+	case SortSize, SortInterleaved, SortSeeders, SortQuality:
+		return true
+	}
+	return false
+}
+
+// loadConfig reads the configuration from CONFIG_FILE (if set) and environment variables,
+// environment variables override values from the file
+func loadConfig() Config {
+	var s settings
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		file, err := loadConfigFile(path)
+		if err != nil {
+			log.Fatalf("❌ Failed to load config file %s: %v", path, err)
+		}
+		s.file = file
+		log.Printf("📄 Loaded %d settings from %s", len(file), path)
+	}
+
+	config := Config{
+		DebridService:    s.getEnvChoice("DEBRID_SERVICE", debridTorBox, []string{debridTorBox, debridRealDebrid, debridAllDebrid}),
+		RealDebridAPIKey: s.getSetting("REALDEBRID_API_KEY"),
+		AllDebridAPIKey:  s.getSetting("ALLDEBRID_API_KEY"),
+		TorBoxAPIKey:     s.getSetting("TORBOX_API_KEY"),
+		JackettURL:       s.getSetting("JACKETT_URL"),
+		JackettAPIKey:    s.getSetting("JACKETT_API_KEY"),
+		IndexerType:      s.getEnvChoice("INDEXER_TYPE", scrapers.IndexerJackett, scrapers.IndexerAPIs),
+
+		JackettInstances: s.getEnvJackettInstances("JACKETT_INSTANCES"),
+		TMDBAPIKey:       s.getSetting("TMDB_API_KEY"),
+		Port:             s.getSetting("PORT"),
+		TorBoxAPIURL:     s.getSetting("TORBOX_API_URL"),
+		RealDebridAPIURL: s.getSetting("REALDEBRID_API_URL"),
+		AllDebridAPIURL:  s.getSetting("ALLDEBRID_API_URL"),
+		TMDBAPIURL:       s.getSetting("TMDB_API_URL"),
+		AdminToken:       s.getSetting("ADMIN_TOKEN"),
+		LogFormat:        strings.ToLower(s.getSetting("LOG_FORMAT")),
+		RootMode:         strings.ToLower(s.getSetting("ROOT_MODE")),
+		EnablePprof:      s.getEnvBool("ENABLE_PPROF", false),
+		PprofAddr:        s.getSetting("PPROF_ADDR"),
+		SearchTTL:        s.getEnvDuration("CACHE_SEARCH_TTL", 30*time.Minute),
+		MetadataTTL:      s.getEnvDuration("CACHE_METADATA_TTL", 24*time.Hour),
+		TorBoxTTL:        s.getEnvDuration("CACHE_TORBOX_CHECK_TTL", 10*time.Minute),
+
+		TorBoxUncachedTTL: s.getEnvDuration("CACHE_TORBOX_UNCACHED_TTL", 2*time.Minute),
+
+		CacheCleanupInterval: s.getEnvDuration("CACHE_CLEANUP_INTERVAL", 5*time.Minute),
+		CacheSaveInterval:    s.getEnvSeconds("CACHE_SAVE_INTERVAL", 30*time.Second),
+		CacheMemoryOnly:      s.getEnvBool("CACHE_MEMORY_ONLY", false),
+
+		CachedFirst: s.getEnvBool("CACHED_FIRST", false),
+		SortMode:    s.getEnvSortMode("SORT_MODE", SortSize),
+
+		BingeGroupLimit: s.getEnvInt("BINGE_GROUP_LIMIT", 0),
+
+		MaxActiveStreams: s.getEnvInt("MAX_ACTIVE_STREAMS", 0),
+
+		SlowRequestThreshold: s.getEnvSeconds("SLOW_REQUEST_THRESHOLD", 0),
+		P2PAlternates:        s.getEnvBool("P2P_ALTERNATES", false),
+		StreamName:           s.getSetting("STREAM_NAME"),
+
+		ReadyPosterShape:     s.getEnvChoice("READY_POSTER_SHAPE", "poster", posterShapes),
+		DownloadsPosterShape: s.getEnvChoice("DOWNLOADS_POSTER_SHAPE", "landscape", posterShapes),
+		DownloadsType:        s.getEnvChoice("DOWNLOADS_CATALOG_TYPE", "other", []string{"other", "channel", "tv"}),
+		InstanceTag:          s.getSetting("INSTANCE_TAG"),
+		PlainTitles:          s.getEnvBool("PLAIN_TITLES", false),
+
+		PreferDualAudio: s.getEnvBool("PREFER_DUAL_AUDIO", false),
+		PreferredCodec:  s.getSetting("PREFERRED_CODEC"),
+		SizeRanges:      s.getEnvSizeRanges("QUALITY_SIZE_RANGES", scrapers.DefaultSizeRanges),
+
+		BlockedKeywords: s.getEnvList("BLOCKED_KEYWORDS", nil),
+		BlockedGroups:   s.getEnvList("BLOCKED_GROUPS", nil),
+
+		ShowReliability:        s.getEnvBool("SHOW_RELIABILITY", false),
+		ReliabilityHighSeeders: s.getEnvInt("RELIABILITY_HIGH_SEEDERS", 20),
+		ReliabilityLowSeeders:  s.getEnvInt("RELIABILITY_LOW_SEEDERS", 5),
+		PreferredTrackers:      s.getEnvList("PREFERRED_TRACKERS", nil),
+
+		TorrentDownloadTimeout: s.getEnvSeconds("TORRENT_DOWNLOAD_TIMEOUT", torrentManager.DefaultDownloadTimeout),
+		TorrentMaxBytes:        int64(s.getEnvInt("TORRENT_MAX_SIZE", torrentManager.DefaultMaxTorrentBytes>>20)) << 20,
+
+		MaxScrapeResults: s.getEnvInt("MAX_SCRAPE_RESULTS", scrapers.DefaultMaxResults),
+		SearchLocale:     s.getSetting("SEARCH_LOCALE"),
+		SeriesPackWords:  s.getEnvList("SERIES_PACK_WORDS", nil),
+		UnpaddedQueries:  s.getEnvBool("SEARCH_UNPADDED", false),
+		MovieQueryYear:   s.getEnvBool("SEARCH_MOVIE_YEAR", false),
+
+		SearchOriginalTitle: s.getEnvBool("SEARCH_ORIGINAL_TITLE", false),
+		StripReleaseNoise:   s.getEnvBool("TITLE_STRIP_NOISE", false),
+
+		AllowAdult: s.getEnvBool("ALLOW_ADULT", false),
+
+		TorBoxMaxIdleConns:    s.getEnvInt("TORBOX_MAX_IDLE_CONNS", 32),
+		TorBoxMaxConnsPerHost: s.getEnvInt("TORBOX_MAX_CONNS_PER_HOST", 32),
+		TorBoxDisableHTTP2:    s.getEnvBool("TORBOX_DISABLE_HTTP2", false),
+
+		StreamTimeout:  s.getEnvSeconds("STREAM_TIMEOUT", 30*time.Second),
+		SearchTimeout:  s.getEnvSeconds("SEARCH_TIMEOUT", 20*time.Second),
+		JackettTimeout: s.getEnvSeconds("JACKETT_TIMEOUT", 20*time.Second),
+		DebridTimeout:  s.getEnvSeconds("DEBRID_TIMEOUT", 25*time.Second),
+
+		SearchEmptyRetryDelay: s.getEnvSeconds("SEARCH_EMPTY_RETRY_DELAY", 0),
+
+		UncachedWait: s.getEnvSeconds("UNCACHED_WAIT_SECONDS", 0),
+
+		WebhookSecret: s.getSetting("TORBOX_WEBHOOK_SECRET"),
+
+		LazyUnrestrict: s.getEnvBool("LAZY_UNRESTRICT", false),
+		PublicURL:      strings.TrimSuffix(s.getSetting("PUBLIC_URL"), "/"),
+		ResolveSecret:  s.getSetting("RESOLVE_SECRET"),
+		ResolveLinkTTL: s.getEnvDuration("RESOLVE_LINK_TTL", 24*time.Hour),
+
+		UnrestrictConcurrency: s.getEnvInt("UNRESTRICT_CONCURRENCY", 4),
+
+		TorBoxRateLimit: s.getEnvFloat("TORBOX_RATE_LIMIT", 5),
+		TorBoxRateBurst: s.getEnvInt("TORBOX_RATE_BURST", 10),
+
+		PrefetchUserDedupWindow:     s.getEnvDuration("PREFETCH_USER_DEDUP_WINDOW", 24*time.Hour),
+		PrefetchTrendingDedupWindow: s.getEnvDuration("PREFETCH_TRENDING_DEDUP_WINDOW", 24*time.Hour),
+		PrefetchPacksFirst:          s.getEnvBool("PREFETCH_PACKS_FIRST", true),
+		PrefetchPacksOnly:           s.getEnvBool("PREFETCH_PACKS_ONLY", false),
+		PrefetchConcurrency:         s.getEnvInt("PREFETCH_CONCURRENCY", 5),
+		PrefetchMaxSearches:         s.getEnvInt("PREFETCH_MAX_SEARCHES", 5),
+		PrefetchMovieQualities:      s.getEnvList("PREFETCH_MOVIE_QUALITIES", []string{"1080p", "2160p"}),
+		PrefetchTrending:            s.getEnvBool("PREFETCH_TRENDING", true),
+		PrefetchOnWatch:             s.getEnvBool("PREFETCH_ON_WATCH", true),
+		PrefetchTrendingMinAge:      time.Duration(s.getEnvInt("PREFETCH_TRENDING_MIN_AGE", 0)) * 24 * time.Hour,
+
+		ReadyCatalog:     s.getEnvBool("READY_CATALOG", false),
+		DownloadsCatalog: s.getEnvBool("DOWNLOADS_CATALOG", false),
+		CloudCatalog:     s.getEnvBool("CLOUD_CATALOG", false),
+
+		AnimeAbsoluteEpisodes: s.getEnvBool("ANIME_ABSOLUTE_EPISODES", false),
+		EpisodeLookahead:      min(s.getEnvInt("EPISODE_LOOKAHEAD", 0), 2),
+		EpisodeBestGuess:      s.getEnvBool("EPISODE_BEST_GUESS", false),
+		SeriesMeta:            s.getEnvBool("SERIES_META", false),
+
+		MovieFileMode:    s.getEnvMovieFileMode("MOVIE_FILE_MODE", MovieFilesLargest),
+		MovieFileMinSize: int64(s.getEnvInt("MOVIE_FILE_MIN_SIZE", 1024)) * 1024 * 1024,
+
+		VideoExtensions: s.getEnvList("VIDEO_EXTENSIONS", nil),
+
+		ContainerExtensions: s.getEnvList("PLAYABLE_CONTAINERS", nil),
+		FallbackTrackers:    s.getEnvList("FALLBACK_TRACKERS", utils.DefaultTrackers),
+		TrackersURL:         s.getSetting("TRACKERS_URL"),
+		TrackersRefresh:     s.getEnvDuration("TRACKERS_REFRESH", 24*time.Hour),
+		P2PMinSeeders:       s.getEnvInt("P2P_MIN_SEEDERS", 0),
+	}
+
+	if config.JackettURL == "" {
+		switch config.IndexerType {
+		case scrapers.IndexerJackett:
+			config.JackettURL = "http://localhost:9117"
+		case scrapers.IndexerProwlarr:
+			config.JackettURL = "http://localhost:9696"
+		}
+	}
+	if config.Port == "" {
+		config.Port = "8080"
+	}
+	if config.PprofAddr == "" {
+		config.PprofAddr = "localhost:6060"
+	}
+	if config.SearchLocale == "" {
+		config.SearchLocale = scrapers.DefaultLocale
+	}
+	if len(config.SeriesPackWords) == 0 {
+		config.SeriesPackWords = scrapers.SeriesPackWords(config.SearchLocale)
+	}
+	if config.PreferredCodec == "" {
+		config.PreferredCodec = "x265"
+	}
+	// Normalise x265/hevc to the codec label of stream titles, "none" disables the preference
+	config.PreferredCodec = utils.ExtractCodec(config.PreferredCodec)
+
+	if config.LogFormat != "json" {
+		config.LogFormat = "text"
+	}
+	switch config.RootMode {
+	case RootJSON, RootRedirect, RootHTML:
+	case "":
+		config.RootMode = RootJSON
+	default:
+		log.Printf("⚠️  Invalid ROOT_MODE %q, using %q", config.RootMode, RootJSON)
+		config.RootMode = RootJSON
+	}
+	if config.StreamTimeout <= 0 {
+		config.StreamTimeout = 30 * time.Second
+	}
+	// A search running until the stream deadline leaves no time to check the debrid cache
+	if config.SearchTimeout <= 0 || config.SearchTimeout >= config.StreamTimeout {
+		log.Printf("⚠️  SEARCH_TIMEOUT %v must be below STREAM_TIMEOUT %v, using %v", config.SearchTimeout, config.StreamTimeout, config.StreamTimeout*2/3)
+		config.SearchTimeout = config.StreamTimeout * 2 / 3
+	}
+	if config.JackettTimeout > config.SearchTimeout {
+		log.Printf("⚠️  JACKETT_TIMEOUT %v exceeds SEARCH_TIMEOUT %v, slow indexers will be cut off by the search deadline", config.JackettTimeout, config.SearchTimeout)
+	}
+	if config.DebridTimeout >= config.StreamTimeout {
+		log.Printf("⚠️  DEBRID_TIMEOUT %v is not below STREAM_TIMEOUT %v, a slow debrid call can use up the whole request", config.DebridTimeout, config.StreamTimeout)
+	}
+
+	if config.LazyUnrestrict && config.PublicURL == "" {
+		log.Println("⚠️  LAZY_UNRESTRICT needs PUBLIC_URL, requesting download links upfront")
+		config.LazyUnrestrict = false
+	}
+	if config.LazyUnrestrict && config.ResolveSecret == "" {
+		log.Println("⚠️  RESOLVE_SECRET not set, /resolve links stop working when the addon restarts")
+		config.ResolveSecret = newResolveSecret()
+	}
+
+	return config
+}
+
+// settings looks up the values loadConfig reads: an environment variable when set, even to "",
+// else the config file value
+type settings struct {
+	file map[string]settingValue
+}
+
+// settingValue is a config file value: a scalar, or the items of a list or object
+type settingValue struct {
+	scalar string
+	items  []string
+	isList bool
+}
+
+// getSetting returns the value of key, config file lists joined with commas
+func (s settings) getSetting(key string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	value := s.file[key]
+	if value.isList {
+		return strings.Join(value.items, ",")
+	}
+	return value.scalar
+}
+
+// getList returns the items of key, splitting scalar values on commas. Config file lists
+// are kept as they are, so their items may contain commas
+func (s settings) getList(key string) []string {
+	if value, ok := os.LookupEnv(key); ok {
+		return splitList(value)
+	}
+	value := s.file[key]
+	if !value.isList {
+		return splitList(value.scalar)
+	}
+
+	var list []string
+	for _, item := range value.items {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// splitList splits a comma-separated list, dropping empty items
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// loadConfigFile reads a JSON, or YAML when named *.yaml or *.yml, config file whose keys are
+// the environment variable names. Lists may be arrays and QUALITY_SIZE_RANGES an object, e.g.
+// {"BLOCKED_GROUPS": ["YIFY"], "CACHE_SEARCH_TTL": 60, "QUALITY_SIZE_RANGES": {"4K": "2048-122880"}}
+func loadConfigFile(path string) (map[string]settingValue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("invalid YAML: %w", err)
+		}
+	default:
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+	}
+
+	file := make(map[string]settingValue, len(raw))
+	for key, value := range raw {
+		setting, err := newSettingValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		file[strings.ToUpper(key)] = setting
+	}
+	return file, nil
+}
+
+// newSettingValue converts a decoded config file value, objects becoming sorted "key=value" items
+func newSettingValue(value interface{}) (settingValue, error) {
+	switch v := value.(type) {
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			str, err := scalarString(item)
+			if err != nil {
+				return settingValue{}, err
+			}
+			items = append(items, str)
+		}
+		return settingValue{items: items, isList: true}, nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		items := make([]string, 0, len(v))
+		for _, key := range keys {
+			str, err := scalarString(v[key])
+			if err != nil {
+				return settingValue{}, err
+			}
+			items = append(items, key+"="+str)
+		}
+		return settingValue{items: items, isList: true}, nil
+	}
+
+	str, err := scalarString(value)
+	return settingValue{scalar: str}, err
+}
+
+// scalarString converts a decoded config file scalar to its environment variable form
+func scalarString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	}
+	return "", fmt.Errorf("unsupported value %v", value)
+}
+
+// getEnvDuration reads a duration from environment variable (in minutes) or returns a default
+func (s settings) getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := s.getSetting(key); value != "" {
+		if minutes, err := strconv.Atoi(value); err == nil {
+			return time.Duration(minutes) * time.Minute
+		}
+		log.Printf("⚠️  Invalid value for %s: %s, using default", key, value)
+	}
+	return defaultValue
+}
+
+// getEnvSeconds reads a duration from environment variable (in seconds) or returns a default
+func (s settings) getEnvSeconds(key string, defaultValue time.Duration) time.Duration {
+	if value := s.getSetting(key); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil {
+			return time.Duration(seconds) * time.Second
+		}
+		log.Printf("⚠️  Invalid value for %s: %s, using default", key, value)
+	}
+	return defaultValue
+}
+
+// getEnvBool reads a boolean from environment variable or returns a default
+func (s settings) getEnvBool(key string, defaultValue bool) bool {
+	if value := s.getSetting(key); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+		log.Printf("⚠️  Invalid value for %s: %s, using default", key, value)
+	}
+	return defaultValue
+}
+
+// getEnvInt reads an integer from environment variable or returns a default
+func (s settings) getEnvInt(key string, defaultValue int) int {
+	if value := s.getSetting(key); value != "" {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+		log.Printf("⚠️  Invalid value for %s: %s, using default", key, value)
+	}
+	return defaultValue
+}
+
+// getEnvFloat reads a float from environment variable or returns a default
+func (s settings) getEnvFloat(key string, defaultValue float64) float64 {
+	if value := s.getSetting(key); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+		log.Printf("⚠️  Invalid value for %s: %s, using default", key, value)
+	}
+	return defaultValue
+}
+
+// getEnvList reads a comma-separated list from environment variable or returns a default
+func (s settings) getEnvList(key string, defaultValue []string) []string {
+	if list := s.getList(key); len(list) > 0 {
+		return list
+	}
+	return defaultValue
+}
+
+// posterShapes are the tile shapes Stremio supports
+var posterShapes = []string{"poster", "landscape", "square"}
+
+// getEnvJackettInstances parses comma-separated "name|url|apikey[|type]" indexer instances,
+// the type being one of scrapers.IndexerAPIs (default jackett)
+func (s settings) getEnvJackettInstances(key string) []scrapers.JackettInstance {
+	var instances []scrapers.JackettInstance
+	for _, item := range s.getEnvList(key, nil) {
+		parts := strings.Split(item, "|")
+		if len(parts) < 3 || len(parts) > 4 || parts[1] == "" {
+			log.Printf("⚠️  Invalid %s entry %q, expected name|url|apikey[|type]", key, item)
+			continue
+		}
+		api := scrapers.IndexerJackett
+		if len(parts) == 4 {
+			api = strings.ToLower(strings.TrimSpace(parts[3]))
+			if !slices.Contains(scrapers.IndexerAPIs, api) {
+				log.Printf("⚠️  Invalid %s entry %q, unknown type %q", key, item, api)
+				continue
+			}
+		}
+		instances = append(instances, scrapers.JackettInstance{
+			Name:   strings.TrimSpace(parts[0]),
+			URL:    strings.TrimSuffix(strings.TrimSpace(parts[1]), "/"),
+			APIKey: strings.TrimSpace(parts[2]),
+			API:    api,
+		})
+	}
+	return instances
+}
+
+// getEnvChoice reads one of the allowed values from environment variable or returns a default
+func (s settings) getEnvChoice(key string, defaultValue string, allowed []string) string {
+	value := strings.ToLower(strings.TrimSpace(s.getSetting(key)))
+	if value == "" {
+		return defaultValue
+	}
+	if slices.Contains(allowed, value) {
+		return value
+	}
+
+	log.Printf("⚠️  Invalid %s %q, using %q", key, value, defaultValue)
+	return defaultValue
+}
+
+// getEnvSortMode reads a stream sort mode from environment variable or returns a default
+func (s settings) getEnvSortMode(key string, defaultValue string) string {
+	value := strings.ToLower(strings.TrimSpace(s.getSetting(key)))
+	if value == "" {
+		return defaultValue
+	}
+	if validSortMode(value) {
+		return value
+	}
+
+	log.Printf("⚠️  Invalid %s %q, using %q", key, value, defaultValue)
+	return defaultValue
+}
+
+// getEnvMovieFileMode reads a movie file selection mode from environment variable or returns a default
+func (s settings) getEnvMovieFileMode(key string, defaultValue string) string {
+	value := strings.ToLower(strings.TrimSpace(s.getSetting(key)))
+	switch value {
+	case MovieFilesLargest, MovieFilesAll, MovieFilesThreshold:
+		return value
+	case "":
+		return defaultValue
+	}
+
+	log.Printf("⚠️  Invalid %s %q, using %q", key, value, defaultValue)
+	return defaultValue
+}
+
+// getEnvSizeRanges reads per-quality size ranges in MB (e.g. "4K=2048-122880,480p=100-5120")
+// and merges them over the defaults
+func (s settings) getEnvSizeRanges(key string, defaultValue map[string]scrapers.SizeRange) map[string]scrapers.SizeRange {
+	ranges := make(map[string]scrapers.SizeRange, len(defaultValue))
+	for quality, sizeRange := range defaultValue {
+		ranges[quality] = sizeRange
+	}
+
+	for _, entry := range s.getList(key) {
+		quality, bounds, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			log.Printf("⚠️  Invalid entry for %s: %s, ignoring", key, entry)
+			continue
+		}
+		minStr, maxStr, _ := strings.Cut(bounds, "-")
+		minMB, errMin := strconv.ParseInt(strings.TrimSpace(minStr), 10, 64)
+		maxMB, errMax := strconv.ParseInt(strings.TrimSpace(maxStr), 10, 64)
+		if errMin != nil || (maxStr != "" && errMax != nil) {
+			log.Printf("⚠️  Invalid entry for %s: %s, ignoring", key, entry)
+			continue
+		}
+		ranges[strings.TrimSpace(quality)] = scrapers.SizeRange{Min: minMB << 20, Max: maxMB << 20}
+	}
+
+	return ranges
+}
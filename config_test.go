@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// writeConfigFile writes a config file named name and points CONFIG_FILE at it
+func writeConfigFile(t *testing.T, name, content string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_FILE", path)
+}
+
+// unsetEnv clears key for the test, restoring it afterwards
+func unsetEnv(t *testing.T, key string) {
+	t.Helper()
+	t.Setenv(key, "")
+	os.Unsetenv(key)
+}
+
+func TestConfigFileFormats(t *testing.T) {
+	files := map[string]string{
+		"config.json": `{
+			"TMDB_API_KEY": "file-key",
+			"CACHE_SEARCH_TTL": 60,
+			"BLOCKED_KEYWORDS": ["cam, ts", "hdcam"],
+			"QUALITY_SIZE_RANGES": {"4K": "2048-122880"}
+		}`,
+		"config.yaml": `
+TMDB_API_KEY: file-key
+CACHE_SEARCH_TTL: 60
+BLOCKED_KEYWORDS:
+  - cam, ts
+  - hdcam
+QUALITY_SIZE_RANGES:
+  4K: 2048-122880
+`,
+	}
+	for _, key := range []string{"TMDB_API_KEY", "CACHE_SEARCH_TTL", "BLOCKED_KEYWORDS", "QUALITY_SIZE_RANGES"} {
+		unsetEnv(t, key)
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			writeConfigFile(t, name, content)
+			config := loadConfig()
+
+			if config.TMDBAPIKey != "file-key" {
+				t.Errorf("TMDBAPIKey = %q, want file-key", config.TMDBAPIKey)
+			}
+			if config.SearchTTL.Minutes() != 60 {
+				t.Errorf("SearchTTL = %v, want 1h", config.SearchTTL)
+			}
+			// List items are kept whole, commas included
+			if want := []string{"cam, ts", "hdcam"}; !slices.Equal(config.BlockedKeywords, want) {
+				t.Errorf("BlockedKeywords = %q, want %q", config.BlockedKeywords, want)
+			}
+			if got := config.SizeRanges["4K"]; got.Min != 2048<<20 || got.Max != 122880<<20 {
+				t.Errorf("SizeRanges[4K] = %+v, want 2048-122880 MB", got)
+			}
+		})
+	}
+}
+
+func TestConfigEnvOverridesFile(t *testing.T) {
+	writeConfigFile(t, "config.json", `{"TMDB_API_KEY": "file-key", "BLOCKED_GROUPS": ["YIFY"]}`)
+
+	t.Setenv("TMDB_API_KEY", "env-key")
+	t.Setenv("BLOCKED_GROUPS", "RARBG,EVO")
+	config := loadConfig()
+	if config.TMDBAPIKey != "env-key" {
+		t.Errorf("TMDBAPIKey = %q, want the environment to win", config.TMDBAPIKey)
+	}
+	if want := []string{"RARBG", "EVO"}; !slices.Equal(config.BlockedGroups, want) {
+		t.Errorf("BlockedGroups = %q, want %q", config.BlockedGroups, want)
+	}
+
+	// A variable set to "" still overrides the file
+	t.Setenv("TMDB_API_KEY", "")
+	t.Setenv("BLOCKED_GROUPS", "")
+	config = loadConfig()
+	if config.TMDBAPIKey != "" {
+		t.Errorf("TMDBAPIKey = %q with TMDB_API_KEY=\"\", want it empty", config.TMDBAPIKey)
+	}
+	if len(config.BlockedGroups) != 0 {
+		t.Errorf("BlockedGroups = %q with BLOCKED_GROUPS=\"\", want none", config.BlockedGroups)
+	}
+}
+
+func TestConfigFileDoesNotLeak(t *testing.T) {
+	unsetEnv(t, "TMDB_API_KEY")
+	writeConfigFile(t, "config.json", `{"TMDB_API_KEY": "file-key"}`)
+	if got := loadConfig().TMDBAPIKey; got != "file-key" {
+		t.Fatalf("TMDBAPIKey = %q, want file-key", got)
+	}
+
+	t.Setenv("CONFIG_FILE", "")
+	if got := loadConfig().TMDBAPIKey; got != "" {
+		t.Errorf("TMDBAPIKey = %q without a config file, want the previous file's value gone", got)
+	}
+}
@@ -0,0 +1,461 @@
+package debrid
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"stremfy/types"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultAllDebridURL is the AllDebrid API root
+	DefaultAllDebridURL = "https://api.alldebrid.com/v4"
+
+	// adStatusReady is the magnet status code of a finished download
+	adStatusReady = 4
+
+	// adMagnetListTTL is how long the listed magnets of the account are reused to find a hash's magnet
+	adMagnetListTTL = time.Minute
+)
+
+// AllDebrid API endpoints
+const (
+	adInstantPath = "/magnet/instant"
+	adUploadPath  = "/magnet/upload"
+	adStatusPath  = "/magnet/status"
+	adUnlockPath  = "/link/unlock"
+)
+
+// AllDebridClient represents an AllDebrid API client
+type AllDebridClient struct {
+	name       string
+	baseURL    string
+	apiKey     string
+	agent      string
+	httpClient *http.Client
+	cache      types.Cache
+	cacheTTL   time.Duration
+	limiter    *rateLimiter
+
+	// magnetIDs maps the hashes of the account's magnets to their IDs, as listed at magnetsListedAt
+	magnetsMu       sync.Mutex
+	magnetIDs       map[string]string
+	magnetsListedAt time.Time
+}
+
+// NewAllDebridClient creates a new AllDebrid client, UncachedTTL, SortPriority and StoreToCloud are unused
+func NewAllDebridClient(config Config) *AllDebridClient {
+	if config.Timeout == 0 {
+		config.Timeout = 28 * time.Second
+	}
+	if config.BaseURL == "" {
+		config.BaseURL = DefaultAllDebridURL
+	}
+	if config.Name == "" {
+		config.Name = "AllDebrid"
+	}
+	if config.Agent == "" {
+		config.Agent = "stremfy"
+	}
+
+	return &AllDebridClient{
+		name:       config.Name,
+		baseURL:    strings.TrimSuffix(config.BaseURL, "/"),
+		apiKey:     config.APIKey,
+		agent:      config.Agent,
+		httpClient: newHTTPClient(config),
+		cache:      config.Cache,
+		cacheTTL:   config.CacheTTL,
+		limiter:    newRateLimiter(config.RateLimit, config.RateBurst),
+	}
+}
+
+// adFile is a file or, with Entries, a folder of a magnet
+type adFile struct {
+	Name    string   `json:"n"`
+	Size    int64    `json:"s"`
+	Entries []adFile `json:"e"`
+}
+
+// adLink is a downloadable file of a ready magnet
+type adLink struct {
+	Link     string `json:"link"`
+	Filename string `json:"filename"`
+	Size     int64  `json:"size"`
+}
+
+// adMagnetStatus is a magnet in the /magnet/status response
+type adMagnetStatus struct {
+	ID         int      `json:"id"`
+	Hash       string   `json:"hash"`
+	Filename   string   `json:"filename"`
+	Status     string   `json:"status"`
+	StatusCode int      `json:"statusCode"`
+	Links      []adLink `json:"links"`
+}
+
+// Name returns the provider label shown in stream names
+func (c *AllDebridClient) Name() string {
+	return c.name
+}
+
+// request makes an HTTP request to the AllDebrid API and returns the data of its response envelope
+func (c *AllDebridClient) request(ctx context.Context, method, endpoint string, params url.Values, formData url.Values) (json.RawMessage, error) {
+	if c.apiKey == "" {
+		return nil, fmt.Errorf("%w: API key is required", ErrUnauthorized)
+	}
+
+	fullURL := c.baseURL + endpoint
+	if len(params) > 0 {
+		fullURL += "?" + params.Encode()
+	}
+
+	var body io.Reader
+	if formData != nil {
+		body = strings.NewReader(formData.Encode())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, fullURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if formData != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	data, err := sendRequest(ctx, c.httpClient, c.limiter, req, c.authorize, classifyAllDebridError)
+	if err != nil {
+		return nil, err
+	}
+
+	// AllDebrid reports most errors with a 200 status
+	var response struct {
+		Status string          `json:"status"`
+		Data   json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if response.Status != "success" {
+		return nil, classifyAllDebridError(http.StatusOK, data)
+	}
+
+	return response.Data, nil
+}
+
+// authorize sends the agent and API key as query params, AllDebrid doesn't take a bearer token
+func (c *AllDebridClient) authorize(req *http.Request) {
+	query := req.URL.Query()
+	query.Set("agent", c.agent)
+	query.Set("apikey", c.apiKey)
+	req.URL.RawQuery = query.Encode()
+}
+
+// generateCacheKey generates a cache key for hash check requests
+func (c *AllDebridClient) generateCacheKey(hashes []string) string {
+	hashesStr := strings.Join(hashes, ",")
+	hash := sha256.Sum256([]byte(hashesStr))
+	return fmt.Sprintf("alldebrid_cache_%x", hash)
+}
+
+// CheckCache checks if multiple hashes are cached
+func (c *AllDebridClient) CheckCache(hashes []string) ([]CacheCheck, error) {
+	return c.CheckCacheWithContext(context.Background(), hashes)
+}
+
+// CheckCacheSingle checks if a single hash is cached
+func (c *AllDebridClient) CheckCacheSingle(ctx context.Context, hash string) ([]CacheCheck, error) {
+	return c.CheckCacheWithContext(ctx, []string{hash})
+}
+
+// CheckCacheWithContext checks if multiple hashes are cached, bound to ctx
+func (c *AllDebridClient) CheckCacheWithContext(ctx context.Context, hashes []string) ([]CacheCheck, error) {
+	if c.cache != nil && !types.SkipCache(ctx) {
+		cacheKey := c.generateCacheKey(hashes)
+		if results, found := types.CacheGet[[]CacheCheck](c.cache, cacheKey); found {
+			fmt.Printf("📦 Cache hit for AllDebrid cache check (%d hashes)\n", len(hashes))
+			return results, nil
+		}
+	}
+
+	form := url.Values{}
+	for _, hash := range hashes {
+		form.Add("magnets[]", hash)
+	}
+
+	data, err := c.request(ctx, http.MethodPost, adInstantPath, nil, form)
+	if errors.Is(err, ErrTorrentNotReady) {
+		// Nothing to stream yet, and not worth failing the request over
+		fmt.Printf("⏳ AllDebrid magnets not ready, treating %d hashes as uncached\n", len(hashes))
+		return []CacheCheck{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Magnets []struct {
+			Hash    string   `json:"hash"`
+			Instant bool     `json:"instant"`
+			Files   []adFile `json:"files"`
+		} `json:"magnets"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	results := []CacheCheck{}
+	for _, magnet := range response.Magnets {
+		if !magnet.Instant {
+			continue
+		}
+		files := numberAllDebridFiles(flattenAllDebridFiles(magnet.Files, "", nil))
+		results = append(results, CacheCheck{Hash: magnet.Hash, Files: files})
+	}
+
+	if c.cache != nil && c.cacheTTL > 0 {
+		cacheKey := c.generateCacheKey(hashes)
+		c.cache.Set(cacheKey, results, c.cacheTTL)
+	}
+
+	return results, nil
+}
+
+// flattenAllDebridFiles lists the files of a magnet's folder tree, see numberAllDebridFiles for their IDs
+func flattenAllDebridFiles(entries []adFile, dir string, files []CachedFileInfo) []CachedFileInfo {
+	for _, entry := range entries {
+		name := path.Join(dir, entry.Name)
+		if len(entry.Entries) > 0 {
+			files = flattenAllDebridFiles(entry.Entries, name, files)
+			continue
+		}
+		files = append(files, CachedFileInfo{Name: name, Size: entry.Size})
+	}
+	return files
+}
+
+// allDebridFileBefore orders files by name then size. The instant check lists a folder tree and
+// the magnet status a flat list of links, so files are numbered in this order in both.
+func allDebridFileBefore(nameA string, sizeA int64, nameB string, sizeB int64) bool {
+	if baseA, baseB := path.Base(nameA), path.Base(nameB); baseA != baseB {
+		return baseA < baseB
+	}
+	return sizeA < sizeB
+}
+
+// numberAllDebridFiles sorts files with allDebridFileBefore and numbers them by position
+func numberAllDebridFiles(files []CachedFileInfo) []CachedFileInfo {
+	sort.SliceStable(files, func(i, j int) bool {
+		return allDebridFileBefore(files[i].Name, files[i].Size, files[j].Name, files[j].Size)
+	})
+	for i := range files {
+		files[i].Index = i
+		files[i].ID = i
+	}
+	return files
+}
+
+// sortAllDebridLinks sorts links like numberAllDebridFiles, so a file ID is the position of its link
+func sortAllDebridLinks(links []adLink) {
+	sort.SliceStable(links, func(i, j int) bool {
+		return allDebridFileBefore(links[i].Filename, links[i].Size, links[j].Filename, links[j].Size)
+	})
+}
+
+// AddMagnet adds a magnet link
+func (c *AllDebridClient) AddMagnet(magnet string) (string, error) {
+	return c.addMagnet(context.Background(), magnet)
+}
+
+// addMagnet adds a magnet link bound to ctx
+func (c *AllDebridClient) addMagnet(ctx context.Context, magnet string) (string, error) {
+	form := url.Values{}
+	form.Add("magnets[]", magnet)
+
+	data, err := c.request(ctx, http.MethodPost, adUploadPath, nil, form)
+	if err != nil {
+		return "", err
+	}
+
+	var response struct {
+		Magnets []struct {
+			ID    int `json:"id"`
+			Error *struct {
+				Code string `json:"code"`
+			} `json:"error"`
+		} `json:"magnets"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if len(response.Magnets) == 0 || response.Magnets[0].ID == 0 {
+		if len(response.Magnets) > 0 && response.Magnets[0].Error != nil {
+			if sentinel, ok := allDebridErrorCodes[response.Magnets[0].Error.Code]; ok {
+				return "", fmt.Errorf("%w: failed to add magnet", sentinel)
+			}
+		}
+		return "", fmt.Errorf("failed to add magnet")
+	}
+
+	return strconv.Itoa(response.Magnets[0].ID), nil
+}
+
+// findMagnet returns the ID of a magnet of the hash already in the account, "" when there is none.
+// AllDebrid can't look a magnet up by hash, so the account's magnets are listed at most every
+// adMagnetListTTL and the listing is reused meanwhile
+func (c *AllDebridClient) findMagnet(ctx context.Context, hash string) (string, error) {
+	hash = strings.ToLower(hash)
+
+	c.magnetsMu.Lock()
+	defer c.magnetsMu.Unlock()
+
+	if time.Since(c.magnetsListedAt) < adMagnetListTTL {
+		return c.magnetIDs[hash], nil
+	}
+
+	data, err := c.request(ctx, http.MethodGet, adStatusPath, nil, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var response struct {
+		Magnets []adMagnetStatus `json:"magnets"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	c.magnetIDs = make(map[string]string, len(response.Magnets))
+	for _, magnet := range response.Magnets {
+		c.magnetIDs[strings.ToLower(magnet.Hash)] = strconv.Itoa(magnet.ID)
+	}
+	c.magnetsListedAt = time.Now()
+	return c.magnetIDs[hash], nil
+}
+
+// rememberMagnet records the ID of a magnet added after the last listing
+func (c *AllDebridClient) rememberMagnet(hash, magnetID string) {
+	c.magnetsMu.Lock()
+	defer c.magnetsMu.Unlock()
+
+	if c.magnetIDs != nil {
+		c.magnetIDs[strings.ToLower(hash)] = magnetID
+	}
+}
+
+// forgetMagnets drops the listed magnets, e.g. when one of them was removed from the account
+func (c *AllDebridClient) forgetMagnets() {
+	c.magnetsMu.Lock()
+	defer c.magnetsMu.Unlock()
+
+	c.magnetIDs, c.magnetsListedAt = nil, time.Time{}
+}
+
+// magnetStatus gets the status and links of an added magnet
+func (c *AllDebridClient) magnetStatus(ctx context.Context, magnetID string) (*adMagnetStatus, error) {
+	params := url.Values{}
+	params.Set("id", magnetID)
+
+	data, err := c.request(ctx, http.MethodGet, adStatusPath, params, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Magnets adMagnetStatus `json:"magnets"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return &response.Magnets, nil
+}
+
+// GetTorrentFiles gets the list of files in a torrent
+func (c *AllDebridClient) GetTorrentFiles(hash string) ([]CachedFileInfo, string, error) {
+	ctx := context.Background()
+
+	// Reuse a magnet already in the account rather than uploading it again on every lookup
+	magnetID, err := c.findMagnet(ctx, hash)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list magnets: %w", err)
+	}
+	if magnetID == "" {
+		magnetID, err = c.addMagnet(ctx, fmt.Sprintf("magnet:?xt=urn:btih:%s", hash))
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to add magnet: %w", err)
+		}
+		c.rememberMagnet(hash, magnetID)
+	}
+
+	status, err := c.magnetStatus(ctx, magnetID)
+	if err != nil {
+		// The magnet may have been removed since the listing
+		c.forgetMagnets()
+		return nil, "", fmt.Errorf("failed to get magnet status: %w", err)
+	}
+	if status.StatusCode != adStatusReady {
+		return nil, magnetID, fmt.Errorf("%w: magnet %s is %s", ErrTorrentNotReady, magnetID, status.Status)
+	}
+
+	// Files are numbered like the instant check, UnrestrictLink sorts the links the same way
+	var files []CachedFileInfo
+	for _, link := range status.Links {
+		files = append(files, CachedFileInfo{Name: link.Filename, Size: link.Size})
+	}
+
+	return numberAllDebridFiles(files), magnetID, nil
+}
+
+// UnrestrictLink unlocks the link of a "magnetID,fileID" file
+func (c *AllDebridClient) UnrestrictLink(fileID string) (string, error) {
+	magnetID, id, ok := strings.Cut(fileID, ",")
+	if !ok {
+		return "", fmt.Errorf("invalid file ID format")
+	}
+	position, err := strconv.Atoi(id)
+	if err != nil {
+		return "", fmt.Errorf("invalid file ID format")
+	}
+
+	ctx := context.Background()
+	status, err := c.magnetStatus(ctx, magnetID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get magnet status: %w", err)
+	}
+	if status.StatusCode != adStatusReady || position < 0 || position >= len(status.Links) {
+		return "", fmt.Errorf("%w: no link for file %s", ErrTorrentNotReady, fileID)
+	}
+	sortAllDebridLinks(status.Links)
+
+	params := url.Values{}
+	params.Set("link", status.Links[position].Link)
+
+	data, err := c.request(ctx, http.MethodGet, adUnlockPath, params, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var response struct {
+		Link    string `json:"link"`
+		Delayed any    `json:"delayed"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if response.Link == "" || response.Delayed != nil {
+		return "", fmt.Errorf("%w: empty download link for %s", ErrTorrentNotReady, fileID)
+	}
+
+	return response.Link, nil
+}
@@ -0,0 +1,120 @@
+package debrid
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestAllDebridFileIDsAgree(t *testing.T) {
+	const hash = "0123456789abcdef0123456789abcdef01234567"
+	var uploads atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		var data string
+		switch {
+		case r.URL.Path == adInstantPath:
+			// A folder tree, in another order than the links of the status
+			data = `{"magnets":[{"hash":"` + hash + `","instant":true,"files":[{"n":"Show","e":[
+				{"n":"Show.S01E02.mkv","s":200},{"n":"Show.S01E01.mkv","s":100},{"n":"Show.nfo","s":1}]}]}]}`
+		case r.URL.Path == adStatusPath && r.Form.Get("id") == "":
+			data = `{"magnets":[{"id":3,"hash":"ffff"},{"id":7,"hash":"` + hash + `"}]}`
+		case r.URL.Path == adStatusPath && r.Form.Get("id") == "7":
+			data = `{"magnets":{"id":7,"hash":"` + hash + `","status":"Ready","statusCode":4,"links":[
+				{"link":"https://ad/nfo","filename":"Show.nfo","size":1},
+				{"link":"https://ad/e02","filename":"Show.S01E02.mkv","size":200},
+				{"link":"https://ad/e01","filename":"Show.S01E01.mkv","size":100}]}}`
+		case r.URL.Path == adUploadPath:
+			uploads.Add(1)
+			data = `{"magnets":[{"id":8}]}`
+		case r.URL.Path == adUnlockPath:
+			data = `{"link":"` + r.Form.Get("link") + `/unlocked"}`
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"status":"success","data":%s}`, data)
+	}))
+	defer server.Close()
+
+	client := NewAllDebridClient(Config{BaseURL: server.URL, APIKey: "key"})
+
+	cached, err := client.CheckCache([]string{hash})
+	if err != nil || len(cached) != 1 {
+		t.Fatalf("CheckCache() = %+v, %v", cached, err)
+	}
+	files, magnetID, err := client.GetTorrentFiles(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if magnetID != "7" || uploads.Load() != 0 {
+		t.Errorf("magnet %s after %d uploads, want the existing magnet 7 reused", magnetID, uploads.Load())
+	}
+
+	instantIDs := make(map[string]int)
+	for _, file := range cached[0].Files {
+		instantIDs[file.Name[len("Show/"):]] = file.ID
+	}
+	for _, file := range files {
+		if id, ok := instantIDs[file.Name]; !ok || id != file.ID {
+			t.Errorf("%s: instant ID %d, file list ID %d", file.Name, id, file.ID)
+		}
+	}
+
+	for _, file := range files {
+		if file.Name != "Show.S01E01.mkv" {
+			continue
+		}
+		link, err := client.UnrestrictLink(file.FileID(magnetID))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if link != "https://ad/e01/unlocked" {
+			t.Errorf("UnrestrictLink(%s) = %s, want the E01 link", file.FileID(magnetID), link)
+		}
+	}
+}
+
+func TestAllDebridMagnetListingIsReused(t *testing.T) {
+	const known, added = "0123456789abcdef0123456789abcdef01234567", "89abcdef0123456789abcdef0123456789abcdef"
+	var listings, uploads atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		var data string
+		switch {
+		case r.URL.Path == adStatusPath && r.Form.Get("id") == "":
+			listings.Add(1)
+			data = `{"magnets":[{"id":7,"hash":"` + known + `"}]}`
+		case r.URL.Path == adStatusPath:
+			data = `{"magnets":{"id":` + r.Form.Get("id") + `,"status":"Ready","statusCode":4,"links":[
+				{"link":"https://ad/movie","filename":"Movie.mkv","size":100}]}}`
+		case r.URL.Path == adUploadPath:
+			uploads.Add(1)
+			data = `{"magnets":[{"id":8}]}`
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"status":"success","data":%s}`, data)
+	}))
+	defer server.Close()
+
+	client := NewAllDebridClient(Config{BaseURL: server.URL, APIKey: "key"})
+	for range 2 {
+		for hash, wantID := range map[string]string{known: "7", added: "8"} {
+			if _, magnetID, err := client.GetTorrentFiles(hash); err != nil || magnetID != wantID {
+				t.Errorf("GetTorrentFiles(%s) = magnet %s, %v, want %s", hash, magnetID, err, wantID)
+			}
+		}
+	}
+	if listings.Load() != 1 || uploads.Load() != 1 {
+		t.Errorf("listed the magnets %d times and uploaded %d, want the listing reused and one upload",
+			listings.Load(), uploads.Load())
+	}
+}
@@ -0,0 +1,72 @@
+package debrid
+
+import (
+	"sort"
+	"stremfy/utils"
+	"time"
+)
+
+// CloudQualities are the resolutions the cloud catalog can be filtered by
+var CloudQualities = []string{"4K", "1080p", "720p"}
+
+// FilterCloudByQuality keeps only torrents whose name parses to the given quality.
+// An empty quality returns the list untouched.
+func FilterCloudByQuality(torrents []TorrentInfo, quality string) []TorrentInfo {
+	if quality == "" {
+		return torrents
+	}
+
+	var filtered []TorrentInfo
+	for _, torrent := range torrents {
+		if utils.ExtractQuality(torrent.Name) == quality {
+			filtered = append(filtered, torrent)
+		}
+	}
+
+	return filtered
+}
+
+// ActiveDownloads returns the torrents still downloading on TorBox
+func ActiveDownloads(torrents []TorrentInfo) []TorrentInfo {
+	var active []TorrentInfo
+	for _, torrent := range torrents {
+		if !torrent.DownloadFinished {
+			active = append(active, torrent)
+		}
+	}
+	return active
+}
+
+// FinishedDownloads returns the torrents fully downloaded to TorBox
+func FinishedDownloads(torrents []TorrentInfo) []TorrentInfo {
+	var finished []TorrentInfo
+	for _, torrent := range torrents {
+		if torrent.DownloadFinished {
+			finished = append(finished, torrent)
+		}
+	}
+	return finished
+}
+
+// DownloadProgress returns the downloaded percentage of a torrent (0-100)
+func DownloadProgress(torrent TorrentInfo) float64 {
+	if torrent.DownloadFinished {
+		return 100
+	}
+	if torrent.Size <= 0 {
+		return 0
+	}
+	return min(float64(torrent.TotalDownloaded)/float64(torrent.Size)*100, 100)
+}
+
+// SortCloudByDateAdded sorts torrents newest first, unparsable dates go last
+func SortCloudByDateAdded(torrents []TorrentInfo) {
+	sort.SliceStable(torrents, func(i, j int) bool {
+		ti, errI := time.Parse(time.RFC3339, torrents[i].CreatedAt)
+		tj, errJ := time.Parse(time.RFC3339, torrents[j].CreatedAt)
+		if errI != nil || errJ != nil {
+			return errI == nil
+		}
+		return ti.After(tj)
+	})
+}
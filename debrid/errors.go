@@ -0,0 +1,121 @@
+package debrid
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Sentinel errors returned (wrapped) by the client, check them with errors.Is
+var (
+	ErrUnauthorized    = errors.New("debrid: unauthorized")
+	ErrRateLimited     = errors.New("debrid: rate limited")
+	ErrNotCached       = errors.New("debrid: torrent not cached")
+	ErrTorrentNotReady = errors.New("debrid: torrent not ready")
+)
+
+// TorBox error codes mapped to sentinel errors
+var torboxErrorCodes = map[string]error{
+	"BAD_TOKEN":               ErrUnauthorized,
+	"AUTH_ERROR":              ErrUnauthorized,
+	"NO_AUTH":                 ErrUnauthorized,
+	"PLAN_RESTRICTED_FEATURE": ErrUnauthorized,
+	"DOWNLOAD_NOT_CACHED":     ErrNotCached,
+	"ACTIVE_LIMIT":            ErrRateLimited,
+	"COOLDOWN_LIMIT":          ErrRateLimited,
+	"MONTHLY_LIMIT":           ErrRateLimited,
+}
+
+// Real-Debrid error codes mapped to sentinel errors
+var realDebridErrorCodes = map[int]error{
+	8:  ErrUnauthorized, // bad_token
+	9:  ErrUnauthorized, // permission_denied
+	14: ErrUnauthorized, // account_locked
+	20: ErrUnauthorized, // premium_only
+	34: ErrRateLimited,  // too_many_requests
+}
+
+// AllDebrid error codes mapped to sentinel errors
+var allDebridErrorCodes = map[string]error{
+	"AUTH_MISSING_AGENT":     ErrUnauthorized,
+	"AUTH_BAD_AGENT":         ErrUnauthorized,
+	"AUTH_MISSING_APIKEY":    ErrUnauthorized,
+	"AUTH_BAD_APIKEY":        ErrUnauthorized,
+	"AUTH_BLOCKED":           ErrUnauthorized,
+	"AUTH_USER_BANNED":       ErrUnauthorized,
+	"MUST_BE_PREMIUM":        ErrUnauthorized,
+	"MAGNET_MUST_BE_PREMIUM": ErrUnauthorized,
+	"MAGNET_TOO_MANY_ACTIVE": ErrRateLimited,
+	"MAGNET_TOO_MANY":        ErrRateLimited,
+	"MAGNET_NOT_READY":       ErrTorrentNotReady,
+	"MAGNET_PROCESSING":      ErrTorrentNotReady,
+}
+
+// classifyTorBoxError converts a failed TorBox response into an error wrapping the matching sentinel
+func classifyTorBoxError(statusCode int, body []byte) error {
+	var apiResp struct {
+		Error  string `json:"error"`
+		Detail string `json:"detail"`
+	}
+	_ = json.Unmarshal(body, &apiResp)
+
+	if sentinel, ok := torboxErrorCodes[apiResp.Error]; ok {
+		return fmt.Errorf("%w: %s (status %d)", sentinel, apiResp.Detail, statusCode)
+	}
+
+	switch statusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("%w: API error (status %d): %s", ErrUnauthorized, statusCode, string(body))
+	case http.StatusTooManyRequests:
+		return fmt.Errorf("%w: API error (status %d): %s", ErrRateLimited, statusCode, string(body))
+	}
+
+	return fmt.Errorf("API error (status %d): %s", statusCode, string(body))
+}
+
+// classifyRealDebridError converts a failed Real-Debrid response into an error wrapping the matching sentinel
+func classifyRealDebridError(statusCode int, body []byte) error {
+	var apiResp struct {
+		Error     string `json:"error"`
+		ErrorCode int    `json:"error_code"`
+	}
+	_ = json.Unmarshal(body, &apiResp)
+
+	if sentinel, ok := realDebridErrorCodes[apiResp.ErrorCode]; ok {
+		return fmt.Errorf("%w: %s (status %d)", sentinel, apiResp.Error, statusCode)
+	}
+
+	switch statusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("%w: API error (status %d): %s", ErrUnauthorized, statusCode, string(body))
+	case http.StatusTooManyRequests:
+		return fmt.Errorf("%w: API error (status %d): %s", ErrRateLimited, statusCode, string(body))
+	}
+
+	return fmt.Errorf("API error (status %d): %s", statusCode, string(body))
+}
+
+// classifyAllDebridError converts a failed AllDebrid response into an error wrapping the matching sentinel
+func classifyAllDebridError(statusCode int, body []byte) error {
+	var apiResp struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	_ = json.Unmarshal(body, &apiResp)
+
+	if sentinel, ok := allDebridErrorCodes[apiResp.Error.Code]; ok {
+		return fmt.Errorf("%w: %s (status %d)", sentinel, apiResp.Error.Message, statusCode)
+	}
+
+	switch statusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("%w: API error (status %d): %s", ErrUnauthorized, statusCode, string(body))
+	case http.StatusTooManyRequests:
+		return fmt.Errorf("%w: API error (status %d): %s", ErrRateLimited, statusCode, string(body))
+	}
+
+	return fmt.Errorf("API error (status %d): %s", statusCode, string(body))
+}
@@ -0,0 +1,74 @@
+package debrid
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClassifyErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		target error
+	}{
+		{"TorBox bad token", classifyTorBoxError(http.StatusForbidden, []byte(`{"error":"BAD_TOKEN","detail":"invalid"}`)), ErrUnauthorized},
+		{"TorBox not cached", classifyTorBoxError(http.StatusBadRequest, []byte(`{"error":"DOWNLOAD_NOT_CACHED"}`)), ErrNotCached},
+		{"TorBox cooldown", classifyTorBoxError(http.StatusBadRequest, []byte(`{"error":"COOLDOWN_LIMIT"}`)), ErrRateLimited},
+		{"TorBox 429", classifyTorBoxError(http.StatusTooManyRequests, []byte(`slow down`)), ErrRateLimited},
+		{"TorBox 401", classifyTorBoxError(http.StatusUnauthorized, nil), ErrUnauthorized},
+		{"Real-Debrid bad token", classifyRealDebridError(http.StatusUnauthorized, []byte(`{"error":"bad_token","error_code":8}`)), ErrUnauthorized},
+		{"Real-Debrid too many requests", classifyRealDebridError(http.StatusTooManyRequests, []byte(`{"error_code":34}`)), ErrRateLimited},
+		{"AllDebrid bad key", classifyAllDebridError(http.StatusOK, []byte(`{"error":{"code":"AUTH_BAD_APIKEY"}}`)), ErrUnauthorized},
+		{"AllDebrid processing", classifyAllDebridError(http.StatusOK, []byte(`{"error":{"code":"MAGNET_PROCESSING"}}`)), ErrTorrentNotReady},
+	}
+	for _, tt := range tests {
+		if !errors.Is(tt.err, tt.target) {
+			t.Errorf("%s: %v is not %v", tt.name, tt.err, tt.target)
+		}
+	}
+
+	// Unknown failures stay plain errors
+	err := classifyTorBoxError(http.StatusInternalServerError, []byte(`oops`))
+	for _, sentinel := range []error{ErrUnauthorized, ErrRateLimited, ErrNotCached, ErrTorrentNotReady} {
+		if errors.Is(err, sentinel) {
+			t.Errorf("%v is %v, want no sentinel", err, sentinel)
+		}
+	}
+}
+
+func TestTorBoxClientErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer key" {
+			t.Errorf("%s sent Authorization %q, want the API key as a bearer token", r.URL.Path, got)
+		}
+		switch r.URL.Path {
+		case "/torrents/createtorrent":
+			fmt.Fprint(w, `{"success":true,"data":{"torrent_id":42}}`)
+		case "/torrents/mylist":
+			// Added but not downloaded yet, so no files are listed
+			fmt.Fprint(w, `{"success":true,"data":{"id":42,"download_finished":false,"files":[]}}`)
+		case "/torrents/requestdl":
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{BaseURL: server.URL, APIKey: "key"})
+	if _, _, err := client.GetTorrentFiles("0123456789abcdef0123456789abcdef01234567"); !errors.Is(err, ErrTorrentNotReady) {
+		t.Errorf("GetTorrentFiles error = %v, want ErrTorrentNotReady", err)
+	}
+	if _, err := client.UnrestrictLink("42,3"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("UnrestrictLink error = %v, want ErrRateLimited", err)
+	}
+
+	keyless := NewClient(Config{BaseURL: server.URL})
+	if _, err := keyless.AddMagnet("magnet:?xt=urn:btih:0123456789abcdef0123456789abcdef01234567"); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("AddMagnet without a key error = %v, want ErrUnauthorized", err)
+	}
+}
@@ -5,19 +5,96 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 )
 
-var videoExtensions = map[string]bool{
-	".mp4": true, ".mkv": true, ".avi": true, ".mov": true,
-	".wmv": true, ".flv": true, ".webm": true, ".m4v": true,
-	".mpg": true, ".mpeg": true, ".m2ts": true, ".ts": true,
-	".vob": true, ".ogv": true,
+// DefaultVideoExtensions are the extensions recognised as video when none are configured
+var DefaultVideoExtensions = []string{
+	".mp4", ".mkv", ".avi", ".mov",
+	".wmv", ".flv", ".webm", ".m4v",
+	".mpg", ".mpeg", ".m2ts", ".ts",
+	".vob", ".ogv",
 }
 
-// IsVideoFile checks if a filename is a video file based on extension
+var (
+	videoExtensionsMu sync.RWMutex
+	videoExtensions   = extensionSet(DefaultVideoExtensions)
+	// containerExtensions are extra containers (e.g. .iso, .m3u8) only some players handle
+	containerExtensions = map[string]bool{}
+)
+
+// extensionSet normalises extensions to lowercase with a leading dot
+func extensionSet(extensions []string) map[string]bool {
+	set := make(map[string]bool, len(extensions))
+	for _, ext := range extensions {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		set[ext] = true
+	}
+	return set
+}
+
+// SetVideoExtensions replaces the recognised video extensions.
+// Entries prefixed with "+" or "-" instead add to or remove from the defaults,
+// e.g. ["+.divx", "-.ts"].
+func SetVideoExtensions(extensions []string) {
+	var replace, add, remove []string
+	for _, ext := range extensions {
+		switch {
+		case strings.HasPrefix(ext, "+"):
+			add = append(add, ext[1:])
+		case strings.HasPrefix(ext, "-"):
+			remove = append(remove, ext[1:])
+		default:
+			replace = append(replace, ext)
+		}
+	}
+
+	base := DefaultVideoExtensions
+	if len(replace) > 0 {
+		base = replace
+	}
+
+	set := extensionSet(append(append([]string{}, base...), add...))
+	for ext := range extensionSet(remove) {
+		delete(set, ext)
+	}
+
+	videoExtensionsMu.Lock()
+	videoExtensions = set
+	videoExtensionsMu.Unlock()
+}
+
+// SetContainerExtensions sets the extra containers offered as streams besides video files
+func SetContainerExtensions(extensions []string) {
+	set := extensionSet(extensions)
+
+	videoExtensionsMu.Lock()
+	containerExtensions = set
+	videoExtensionsMu.Unlock()
+}
+
+// IsVideoFile checks if a filename is a video file or an allowed container based on extension
 func IsVideoFile(filename string) bool {
 	ext := strings.ToLower(filepath.Ext(filename))
-	return videoExtensions[ext]
+
+	videoExtensionsMu.RLock()
+	defer videoExtensionsMu.RUnlock()
+	return videoExtensions[ext] || containerExtensions[ext]
+}
+
+// IsContainerFile checks if a filename is one of the extra containers, which browsers can't play
+func IsContainerFile(filename string) bool {
+	ext := strings.ToLower(filepath.Ext(filename))
+
+	videoExtensionsMu.RLock()
+	defer videoExtensionsMu.RUnlock()
+	return containerExtensions[ext] && !videoExtensions[ext]
 }
 
 // IsEpisodeFile checks if a filename matches episode patterns
@@ -44,9 +121,16 @@ func IsEpisodeFile(filename string, season, episode int) bool {
 
 		// Episode format: Season 1.01, Season 01.1
 		regexp.MustCompile(fmt.Sprintf(`\bseason\s+0*%d[.\s]+0*%d(?:\D|$)`, season, episode)),
+	}
 
-		// Dotted format: 1.01, 1.1, 01.01 (season.episode)
-		regexp.MustCompile(fmt.Sprintf(`\b0*%d\.0*%d(?:\D|$)`, season, episode)),
+	if season == 0 {
+		// Specials: Special 01, SP01, OVA 1, OAD 01
+		episodePatterns = append(episodePatterns,
+			regexp.MustCompile(fmt.Sprintf(`\b(?:special|sp|ova|oav|oad)[\s\._-]*0*%d(?:\D|$)`, episode)))
+	} else {
+		// Dotted format: 1.01, 1.1, 01.01 (season.episode), too ambiguous for season 0
+		episodePatterns = append(episodePatterns,
+			regexp.MustCompile(fmt.Sprintf(`\b0*%d\.0*%d(?:\D|$)`, season, episode)))
 	}
 
 	// Episode-only patterns (for when season is in folder)
@@ -80,6 +164,9 @@ func IsEpisodeFile(filename string, season, episode int) bool {
 			regexp.MustCompile(fmt.Sprintf(`\bseason[\s\._-]*0*%d(?:\D|$)`, season)),
 			regexp.MustCompile(fmt.Sprintf(`\btemporada[\s\._-]*0*%d(?:\D|$)`, season)),
 		}
+		if season == 0 {
+			seasonPatterns = append(seasonPatterns, regexp.MustCompile(`\b(?:specials?|ovas?|extras)\b`))
+		}
 
 		// Check if directory contains season
 		seasonInDir := false
@@ -103,6 +190,16 @@ func IsEpisodeFile(filename string, season, episode int) bool {
 	return false
 }
 
+// IsAbsoluteEpisodeFile checks if a filename matches an anime-style absolute episode number,
+// e.g. "Show - 29", "Show E29", "Show [29]" or "Show - 29v2"
+func IsAbsoluteEpisodeFile(filename string, absolute int) bool {
+	parts := strings.Split(strings.ToLower(filename), "/")
+	actualFilename := parts[len(parts)-1]
+
+	pattern := regexp.MustCompile(fmt.Sprintf(`(?:\s-\s*|\b(?:e|ep|episode)[\s._]*|\[|#)0*%d(?:v\d)?(?:\D|$)`, absolute))
+	return pattern.MatchString(actualFilename)
+}
+
 // IsFileSizeValid checks if file size meets minimum requirements
 func IsFileSizeValid(size int64, isSeries bool) bool {
 	const minEpisodeSize = 50 * 1024 * 1024 // 50 MB
@@ -113,3 +210,30 @@ func IsFileSizeValid(size int64, isSeries bool) bool {
 	}
 	return size >= minMovieSize
 }
+
+// samplePattern matches "sample" as a path segment or a filename token
+var samplePattern = regexp.MustCompile(`(?i)(^|[/\\\s._-])sample([/\\\s._-]|$)`)
+
+// sampleSizeRatio is the fraction of the largest video below which a file is treated as a sample
+const sampleSizeRatio = 0.05
+
+// IsSampleFile checks if a file is a sample clip, by name/path or by being a tiny
+// fraction of the largest video file in the same torrent
+func IsSampleFile(filename string, size, largestVideoSize int64) bool {
+	if samplePattern.MatchString(filename) {
+		return true
+	}
+	return largestVideoSize > 0 && float64(size) < float64(largestVideoSize)*sampleSizeRatio
+}
+
+// LargestVideoSize returns the size of the largest streamable video in the list, leaving out
+// files not cached yet and files named as samples
+func LargestVideoSize(files []CachedFileInfo) int64 {
+	var largest int64
+	for _, file := range files {
+		if IsVideoFile(file.Name) && file.Available() && !samplePattern.MatchString(file.Name) && file.Size > largest {
+			largest = file.Size
+		}
+	}
+	return largest
+}
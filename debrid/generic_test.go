@@ -0,0 +1,89 @@
+package debrid
+
+import "testing"
+
+func TestSetVideoExtensions(t *testing.T) {
+	t.Cleanup(func() { SetVideoExtensions(nil) })
+
+	tests := []struct {
+		name       string
+		extensions []string
+		want       map[string]bool
+	}{
+		{"defaults", nil, map[string]bool{"a.mkv": true, "a.ts": true, "a.divx": false, "a.nfo": false}},
+		{"add and remove", []string{"+divx", "+.RMVB", "-.ts"}, map[string]bool{"a.mkv": true, "a.divx": true, "a.rmvb": true, "a.ts": false}},
+		{"replace", []string{"mkv", " .MP4 "}, map[string]bool{"a.mkv": true, "a.mp4": true, "a.avi": false, "a.ts": false}},
+		{"replace and add", []string{".mkv", "+.divx"}, map[string]bool{"a.mkv": true, "a.divx": true, "a.mp4": false}},
+	}
+	for _, tt := range tests {
+		SetVideoExtensions(tt.extensions)
+		for filename, want := range tt.want {
+			if got := IsVideoFile(filename); got != want {
+				t.Errorf("%s: IsVideoFile(%q) = %v, want %v", tt.name, filename, got, want)
+			}
+		}
+	}
+}
+
+func TestIsSampleFile(t *testing.T) {
+	const largest = 4 << 30
+
+	tests := []struct {
+		filename string
+		size     int64
+		want     bool
+	}{
+		{"Movie.2020.1080p/Movie.2020.1080p.mkv", largest, false},
+		{"Movie.2020.1080p/sample.mkv", 300 << 20, true},
+		{"Movie.2020.1080p/Sample/movie-clip.mkv", 300 << 20, true},
+		{"Movie.2020.1080p/movie.2020.1080p-sample.mkv", 300 << 20, true},
+		// A tiny fraction of the main video, whatever its name
+		{"Movie.2020.1080p/Movie.2020.1080p.trailer.mkv", 100 << 20, true},
+		// "sample" inside a word isn't a sample tag
+		{"Samples.From.Space.2020/Samples.From.Space.2020.mkv", largest, false},
+	}
+	for _, tt := range tests {
+		if got := IsSampleFile(tt.filename, tt.size, largest); got != tt.want {
+			t.Errorf("IsSampleFile(%q, %d) = %v, want %v", tt.filename, tt.size, got, tt.want)
+		}
+	}
+
+	if IsSampleFile("Movie.mkv", 100, 0) {
+		t.Error("IsSampleFile without a known largest video = true, want false")
+	}
+}
+
+func TestLargestVideoSize(t *testing.T) {
+	notCached := false
+	files := []CachedFileInfo{
+		{Name: "Movie/movie.mkv", Size: 2 << 30},
+		{Name: "Movie/extras.iso", Size: 8 << 30},
+		{Name: "Movie/sample.mkv", Size: 50 << 20},
+		{Name: "Movie/Sample/movie.sample.mkv", Size: 3 << 30},
+		{Name: "Movie/movie.remux.mkv", Size: 6 << 30, Cached: &notCached},
+	}
+	if got := LargestVideoSize(files); got != 2<<30 {
+		t.Errorf("LargestVideoSize = %d, want %d", got, 2<<30)
+	}
+}
+
+func TestIsEpisodeFileSpecials(t *testing.T) {
+	tests := []struct {
+		filename string
+		episode  int
+		want     bool
+	}{
+		{"Doctor.Who.S00E01.The.Christmas.Invasion.mkv", 1, true},
+		{"Doctor Who/Specials/Doctor.Who.Special.01.mkv", 1, true},
+		{"Frieren/[Group] Frieren - OVA 2 [1080p].mkv", 2, true},
+		{"Show.SP03.720p.mkv", 3, true},
+		{"Doctor.Who.S00E11.mkv", 1, false},
+		{"Doctor.Who.S01E01.Rose.mkv", 1, false},
+		{"Doctor.Who.Special.10.mkv", 1, false},
+	}
+	for _, tt := range tests {
+		if got := IsEpisodeFile(tt.filename, 0, tt.episode); got != tt.want {
+			t.Errorf("IsEpisodeFile(%q, 0, %d) = %v, want %v", tt.filename, tt.episode, got, tt.want)
+		}
+	}
+}
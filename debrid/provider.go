@@ -0,0 +1,32 @@
+package debrid
+
+import "context"
+
+// Provider is a debrid service the addon streams through
+type Provider interface {
+	// Name returns the provider label shown in stream names
+	Name() string
+	// CheckCache returns the hashes that are cached, with their files when the service lists them
+	CheckCache(hashes []string) ([]CacheCheck, error)
+	CheckCacheWithContext(ctx context.Context, hashes []string) ([]CacheCheck, error)
+	CheckCacheSingle(ctx context.Context, hash string) ([]CacheCheck, error)
+	// GetTorrentFiles adds the torrent and lists its files, returning the torrent ID
+	GetTorrentFiles(hash string) ([]CachedFileInfo, string, error)
+	// UnrestrictLink returns the download link of a "torrentID,fileID" file, see CachedFileInfo.FileID
+	UnrestrictLink(fileID string) (string, error)
+	// AddMagnet adds a magnet and returns the torrent ID
+	AddMagnet(magnet string) (string, error)
+}
+
+// CloudProvider is a Provider whose downloads can be listed and polled
+type CloudProvider interface {
+	Provider
+	TorrentInfo(requestID string) (*TorrentInfo, error)
+	UserCloud(requestID string) ([]TorrentInfo, error)
+}
+
+var (
+	_ CloudProvider = (*Client)(nil)
+	_ Provider      = (*RealDebridClient)(nil)
+	_ Provider      = (*AllDebridClient)(nil)
+)
@@ -0,0 +1,411 @@
+package debrid
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"stremfy/types"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultRealDebridURL is the Real-Debrid API root
+	DefaultRealDebridURL = "https://api.real-debrid.com/rest/1.0"
+
+	// rdAvailabilityBatch is the number of hashes per instantAvailability request, bounded by the URL length
+	rdAvailabilityBatch = 100
+
+	// rdListLimit is how many of the most recent account torrents are searched for a hash
+	rdListLimit = 500
+)
+
+// Real-Debrid API endpoints. instantAvailability is deprecated and reports most hashes as
+// uncached, so torrents already downloaded to the account count as cached too.
+const (
+	rdTorrentsPath     = "/torrents"
+	rdAvailabilityPath = "/torrents/instantAvailability/"
+	rdAddMagnetPath    = "/torrents/addMagnet"
+	rdSelectFilesPath  = "/torrents/selectFiles/"
+	rdInfoPath         = "/torrents/info/"
+	rdUnrestrictPath   = "/unrestrict/link"
+)
+
+// RealDebridClient represents a Real-Debrid API client
+type RealDebridClient struct {
+	name       string
+	baseURL    string
+	apiKey     string
+	userAgent  string
+	httpClient *http.Client
+	cache      types.Cache
+	cacheTTL   time.Duration
+	limiter    *rateLimiter
+}
+
+// NewRealDebridClient creates a new Real-Debrid client, UncachedTTL, SortPriority and StoreToCloud are unused
+func NewRealDebridClient(config Config) *RealDebridClient {
+	if config.Timeout == 0 {
+		config.Timeout = 28 * time.Second
+	}
+	if config.BaseURL == "" {
+		config.BaseURL = DefaultRealDebridURL
+	}
+	if config.Name == "" {
+		config.Name = "Real-Debrid"
+	}
+
+	return &RealDebridClient{
+		name:       config.Name,
+		baseURL:    strings.TrimSuffix(config.BaseURL, "/"),
+		apiKey:     config.APIKey,
+		userAgent:  "Mozilla/5.0",
+		httpClient: newHTTPClient(config),
+		cache:      config.Cache,
+		cacheTTL:   config.CacheTTL,
+		limiter:    newRateLimiter(config.RateLimit, config.RateBurst),
+	}
+}
+
+// rdFile is a file of a torrent added to Real-Debrid
+type rdFile struct {
+	ID       int    `json:"id"`
+	Path     string `json:"path"`
+	Bytes    int64  `json:"bytes"`
+	Selected int    `json:"selected"`
+}
+
+// rdTorrentInfo is the /torrents/info response, Links holds one link per selected file
+type rdTorrentInfo struct {
+	ID       string   `json:"id"`
+	Filename string   `json:"filename"`
+	Hash     string   `json:"hash"`
+	Bytes    int64    `json:"bytes"`
+	Status   string   `json:"status"`
+	Files    []rdFile `json:"files"`
+	Links    []string `json:"links"`
+}
+
+// Name returns the provider label shown in stream names
+func (c *RealDebridClient) Name() string {
+	return c.name
+}
+
+// request makes an HTTP request to the Real-Debrid API
+func (c *RealDebridClient) request(ctx context.Context, method, path string, formData url.Values) ([]byte, error) {
+	if c.apiKey == "" {
+		return nil, fmt.Errorf("%w: API key is required", ErrUnauthorized)
+	}
+
+	var body io.Reader
+	if formData != nil {
+		body = strings.NewReader(formData.Encode())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if formData != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	return sendRequest(ctx, c.httpClient, c.limiter, req, c.authorize, classifyRealDebridError)
+}
+
+// authorize sends the API token as a bearer token
+func (c *RealDebridClient) authorize(req *http.Request) {
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	req.Header.Set("User-Agent", c.userAgent)
+}
+
+// generateCacheKey generates a cache key for hash check requests
+func (c *RealDebridClient) generateCacheKey(hashes []string) string {
+	hashesStr := strings.Join(hashes, ",")
+	hash := sha256.Sum256([]byte(hashesStr))
+	return fmt.Sprintf("realdebrid_cache_%x", hash)
+}
+
+// CheckCache checks if multiple hashes are cached
+func (c *RealDebridClient) CheckCache(hashes []string) ([]CacheCheck, error) {
+	return c.CheckCacheWithContext(context.Background(), hashes)
+}
+
+// CheckCacheSingle checks if a single hash is cached
+func (c *RealDebridClient) CheckCacheSingle(ctx context.Context, hash string) ([]CacheCheck, error) {
+	return c.CheckCacheWithContext(ctx, []string{hash})
+}
+
+// CheckCacheWithContext checks if multiple hashes are cached, bound to ctx
+func (c *RealDebridClient) CheckCacheWithContext(ctx context.Context, hashes []string) ([]CacheCheck, error) {
+	if c.cache != nil && !types.SkipCache(ctx) {
+		cacheKey := c.generateCacheKey(hashes)
+		if results, found := types.CacheGet[[]CacheCheck](c.cache, cacheKey); found {
+			fmt.Printf("📦 Cache hit for Real-Debrid cache check (%d hashes)\n", len(hashes))
+			return results, nil
+		}
+	}
+
+	var results []CacheCheck
+	for start := 0; start < len(hashes); start += rdAvailabilityBatch {
+		end := min(start+rdAvailabilityBatch, len(hashes))
+		batch, err := c.instantAvailability(ctx, hashes[start:end])
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, batch...)
+	}
+	results = c.withAccountTorrents(ctx, hashes, results)
+
+	if c.cache != nil && c.cacheTTL > 0 {
+		cacheKey := c.generateCacheKey(hashes)
+		c.cache.Set(cacheKey, results, c.cacheTTL)
+	}
+
+	return results, nil
+}
+
+// instantAvailability checks one batch of hashes, merging the files of every cached variant
+func (c *RealDebridClient) instantAvailability(ctx context.Context, hashes []string) ([]CacheCheck, error) {
+	data, err := c.request(ctx, http.MethodGet, rdAvailabilityPath+strings.Join(hashes, "/"), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Uncached hashes map to an empty array instead of an object
+	var response map[string]json.RawMessage
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	var results []CacheCheck
+	for _, hash := range hashes {
+		var hosts map[string][]map[string]struct {
+			Filename string `json:"filename"`
+			Filesize int64  `json:"filesize"`
+		}
+		if json.Unmarshal(response[strings.ToLower(hash)], &hosts) != nil || len(hosts["rd"]) == 0 {
+			continue
+		}
+
+		seen := make(map[int]bool)
+		var files []CachedFileInfo
+		for _, variant := range hosts["rd"] {
+			for key, file := range variant {
+				id, err := strconv.Atoi(key)
+				if err != nil || seen[id] {
+					continue
+				}
+				seen[id] = true
+				files = append(files, CachedFileInfo{Name: file.Filename, Size: file.Filesize, Index: id - 1, ID: id})
+			}
+		}
+		sort.Slice(files, func(i, j int) bool { return files[i].ID < files[j].ID })
+
+		results = append(results, CacheCheck{Hash: hash, Files: files})
+	}
+
+	return results, nil
+}
+
+// listTorrents lists the most recent torrents of the account
+func (c *RealDebridClient) listTorrents(ctx context.Context) ([]rdTorrentInfo, error) {
+	data, err := c.request(ctx, http.MethodGet, fmt.Sprintf("%s?limit=%d", rdTorrentsPath, rdListLimit), nil)
+	if err != nil {
+		return nil, err
+	}
+	// An empty account answers 204 with no body
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var torrents []rdTorrentInfo
+	if err := json.Unmarshal(data, &torrents); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return torrents, nil
+}
+
+// findTorrent returns the account torrent of a hash, nil when there is none or it failed
+func findTorrent(torrents []rdTorrentInfo, hash string) *rdTorrentInfo {
+	for i, torrent := range torrents {
+		if !strings.EqualFold(torrent.Hash, hash) {
+			continue
+		}
+		switch torrent.Status {
+		case "magnet_error", "error", "virus", "dead":
+			continue
+		}
+		return &torrents[i]
+	}
+	return nil
+}
+
+// withAccountTorrents adds the hashes already downloaded to the account to the cache check results
+func (c *RealDebridClient) withAccountTorrents(ctx context.Context, hashes []string, results []CacheCheck) []CacheCheck {
+	torrents, err := c.listTorrents(ctx)
+	if err != nil {
+		fmt.Printf("⚠️  Failed to list Real-Debrid torrents: %v\n", err)
+		return results
+	}
+
+	found := make(map[string]bool, len(results))
+	for _, result := range results {
+		found[strings.ToLower(result.Hash)] = true
+	}
+	for _, hash := range hashes {
+		if torrent := findTorrent(torrents, hash); torrent != nil && torrent.Status == "downloaded" && !found[strings.ToLower(hash)] {
+			found[strings.ToLower(hash)] = true
+			results = append(results, CacheCheck{Hash: hash})
+		}
+	}
+	return results
+}
+
+// AddMagnet adds a magnet link and selects all of its files so the download starts
+func (c *RealDebridClient) AddMagnet(magnet string) (string, error) {
+	form := url.Values{}
+	form.Set("magnet", magnet)
+
+	data, err := c.request(context.Background(), http.MethodPost, rdAddMagnetPath, form)
+	if err != nil {
+		return "", err
+	}
+
+	var response struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if response.ID == "" {
+		return "", fmt.Errorf("failed to add magnet")
+	}
+
+	form = url.Values{}
+	form.Set("files", "all")
+	if _, err := c.request(context.Background(), http.MethodPost, rdSelectFilesPath+response.ID, form); err != nil {
+		return "", fmt.Errorf("failed to select files: %w", err)
+	}
+
+	return response.ID, nil
+}
+
+// torrentInfo gets the files, status and links of an added torrent
+func (c *RealDebridClient) torrentInfo(torrentID string) (*rdTorrentInfo, error) {
+	data, err := c.request(context.Background(), http.MethodGet, rdInfoPath+url.PathEscape(torrentID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var info rdTorrentInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return &info, nil
+}
+
+// GetTorrentFiles gets the list of files in a torrent, reusing the account torrent of the hash
+// when there is one so lookups don't add the same torrent again
+func (c *RealDebridClient) GetTorrentFiles(hash string) ([]CachedFileInfo, string, error) {
+	torrents, err := c.listTorrents(context.Background())
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list torrents: %w", err)
+	}
+
+	var torrentID string
+	if existing := findTorrent(torrents, hash); existing != nil {
+		torrentID = existing.ID
+	} else {
+		torrentID, err = c.AddMagnet(fmt.Sprintf("magnet:?xt=urn:btih:%s", hash))
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to add magnet: %w", err)
+		}
+	}
+
+	info, err := c.torrentInfo(torrentID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get torrent info: %w", err)
+	}
+
+	if len(info.Files) == 0 {
+		return nil, torrentID, fmt.Errorf("%w: torrent %s has no files yet", ErrTorrentNotReady, torrentID)
+	}
+
+	// Real-Debrid numbers files from 1 in torrent order. Only selected files get a link,
+	// which matters for a reused torrent whose files were picked by hand.
+	var files []CachedFileInfo
+	for _, file := range info.Files {
+		selected := file.Selected != 0
+		files = append(files, CachedFileInfo{
+			Name:   strings.TrimPrefix(file.Path, "/"),
+			Size:   file.Bytes,
+			Index:  file.ID - 1,
+			ID:     file.ID,
+			Cached: &selected,
+		})
+	}
+
+	return files, torrentID, nil
+}
+
+// UnrestrictLink unrestricts the hoster link of a "torrentID,fileID" file
+func (c *RealDebridClient) UnrestrictLink(fileID string) (string, error) {
+	torrentID, id, ok := strings.Cut(fileID, ",")
+	if !ok {
+		return "", fmt.Errorf("invalid file ID format")
+	}
+
+	info, err := c.torrentInfo(torrentID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get torrent info: %w", err)
+	}
+	if info.Status != "downloaded" {
+		return "", fmt.Errorf("%w: torrent %s is %s", ErrTorrentNotReady, torrentID, info.Status)
+	}
+
+	// Links are in the order of the selected files
+	link := ""
+	position := 0
+	for _, file := range info.Files {
+		if file.Selected == 0 {
+			continue
+		}
+		if strconv.Itoa(file.ID) == id {
+			if position < len(info.Links) {
+				link = info.Links[position]
+			}
+			break
+		}
+		position++
+	}
+	if link == "" {
+		return "", fmt.Errorf("%w: no link for file %s", ErrTorrentNotReady, fileID)
+	}
+
+	form := url.Values{}
+	form.Set("link", link)
+
+	data, err := c.request(context.Background(), http.MethodPost, rdUnrestrictPath, form)
+	if err != nil {
+		return "", err
+	}
+
+	var response struct {
+		Download string `json:"download"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if response.Download == "" {
+		return "", fmt.Errorf("%w: empty download link for %s", ErrTorrentNotReady, fileID)
+	}
+
+	return response.Download, nil
+}
@@ -0,0 +1,70 @@
+package debrid
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRealDebridReusesAccountTorrents(t *testing.T) {
+	const hash = "0123456789abcdef0123456789abcdef01234567"
+	var added atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, rdAvailabilityPath):
+			// The deprecated endpoint reports nothing as cached
+			fmt.Fprint(w, `{}`)
+		case r.URL.Path == rdTorrentsPath:
+			fmt.Fprint(w, `[{"id":"OLD","hash":"`+strings.ToUpper(hash)+`","status":"dead"},
+				{"id":"RD1","hash":"`+strings.ToUpper(hash)+`","status":"downloaded"}]`)
+		case r.URL.Path == rdInfoPath+"RD1":
+			fmt.Fprint(w, `{"id":"RD1","hash":"`+hash+`","status":"downloaded",
+				"files":[{"id":1,"path":"/Movie/sample.mkv","bytes":10,"selected":0},
+					{"id":2,"path":"/Movie/movie.mkv","bytes":2000,"selected":1}],
+				"links":["https://rd/hoster/2"]}`)
+		case r.URL.Path == rdUnrestrictPath:
+			r.ParseForm()
+			fmt.Fprintf(w, `{"download":"%s/unrestricted"}`, r.Form.Get("link"))
+		case r.URL.Path == rdAddMagnetPath:
+			added.Add(1)
+			fmt.Fprint(w, `{"id":"NEW"}`)
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewRealDebridClient(Config{BaseURL: server.URL, APIKey: "key"})
+
+	cached, err := client.CheckCache([]string{hash, "ffffffffffffffffffffffffffffffffffffffff"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cached) != 1 || cached[0].Hash != hash {
+		t.Errorf("CheckCache() = %+v, want the downloaded account torrent", cached)
+	}
+
+	files, torrentID, err := client.GetTorrentFiles(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if torrentID != "RD1" || added.Load() != 0 {
+		t.Errorf("torrent %s after %d adds, want the account torrent RD1 reused", torrentID, added.Load())
+	}
+	if len(files) != 2 || files[0].Available() || !files[1].Available() {
+		t.Errorf("files = %+v, want only the selected file available", files)
+	}
+
+	link, err := client.UnrestrictLink(files[1].FileID(torrentID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link != "https://rd/hoster/2/unrestricted" {
+		t.Errorf("UnrestrictLink() = %s, want the link of the selected file", link)
+	}
+}
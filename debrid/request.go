@@ -0,0 +1,37 @@
+package debrid
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// authorizer adds a provider's credentials to a request, e.g. a bearer token or query params
+type authorizer func(req *http.Request)
+
+// sendRequest sends req once the rate limiter allows it, converting failed responses with classify
+func sendRequest(ctx context.Context, client *http.Client, limiter *rateLimiter, req *http.Request, authorize authorizer, classify func(statusCode int, body []byte) error) ([]byte, error) {
+	if err := limiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("rate limiter: %w", err)
+	}
+
+	authorize(req)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, classify(resp.StatusCode, respBody)
+	}
+
+	return respBody, nil
+}
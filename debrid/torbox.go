@@ -1,7 +1,10 @@
 package debrid
 
 import (
+	"context"
 	"crypto/sha256"
+	"crypto/tls"
+	"encoding/gob"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -13,7 +16,8 @@ import (
 )
 
 const (
-	baseURL = "https://api.torbox.app/v1/api"
+	// DefaultBaseURL is the TorBox API root
+	DefaultBaseURL = "https://api.torbox.app/v1/api"
 )
 
 // API endpoints
@@ -27,9 +31,15 @@ const (
 	cloudPath    = "/torrents/createtorrent"
 )
 
+// Register the cached cache-check results, so the persisted cache decodes them
+func init() {
+	gob.Register([]CacheCheck{})
+}
+
 // Client represents a TorBox API client
 type Client struct {
 	name         string
+	baseURL      string
 	apiKey       string
 	userAgent    string
 	sortPriority string
@@ -38,16 +48,37 @@ type Client struct {
 	httpClient   *http.Client
 	cache        types.Cache
 	cacheTTL     time.Duration
+	uncachedTTL  time.Duration
+	limiter      *rateLimiter
 }
 
-// Config holds configuration for the TorBox client
+// Config holds configuration for the debrid clients
 type Config struct {
+	// BaseURL overrides DefaultBaseURL, e.g. to go through a proxy or a fake server
+	BaseURL string
+	// Name labels the provider in stream names (default "TorBox")
+	Name string
+	// Agent identifies the app to AllDebrid, which requires it on every request (default "stremfy")
+	Agent        string
 	APIKey       string
 	SortPriority string
 	StoreToCloud bool
 	Timeout      time.Duration
 	Cache        types.Cache
 	CacheTTL     time.Duration
+	// UncachedTTL remembers hashes reported as not cached so they aren't re-checked (0 disables)
+	UncachedTTL time.Duration
+
+	// Transport tuning for the bursty CheckCache/UnrestrictLink traffic
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int
+	IdleConnTimeout     time.Duration
+	DisableHTTP2        bool
+
+	// RateLimit caps requests per second across all methods (0 disables), RateBurst allows short bursts
+	RateLimit float64
+	RateBurst int
 }
 
 // NewClient creates a new TorBox client
@@ -55,25 +86,61 @@ func NewClient(config Config) *Client {
 	if config.Timeout == 0 {
 		config.Timeout = 28 * time.Second
 	}
-
+	if config.BaseURL == "" {
+		config.BaseURL = DefaultBaseURL
+	}
+	if config.Name == "" {
+		config.Name = "TorBox"
+	}
 	return &Client{
-		name:         "TorBox",
+		name:         config.Name,
+		baseURL:      strings.TrimSuffix(config.BaseURL, "/"),
 		apiKey:       config.APIKey,
 		userAgent:    "Mozilla/5.0",
 		sortPriority: config.SortPriority,
 		storeToCloud: config.StoreToCloud,
 		timeout:      config.Timeout,
-		httpClient: &http.Client{
-			Timeout: config.Timeout,
-			Transport: &http.Transport{
-				MaxIdleConns:        10,
-				IdleConnTimeout:     30 * time.Second,
-				DisableCompression:  false,
-				MaxIdleConnsPerHost: 10,
-			},
-		},
-		cache:    config.Cache,
-		cacheTTL: config.CacheTTL,
+		httpClient:   newHTTPClient(config),
+		cache:        config.Cache,
+		cacheTTL:     config.CacheTTL,
+		uncachedTTL:  config.UncachedTTL,
+		limiter:      newRateLimiter(config.RateLimit, config.RateBurst),
+	}
+}
+
+// newHTTPClient builds the HTTP client with the transport tuning of config
+func newHTTPClient(config Config) *http.Client {
+	if config.MaxIdleConns == 0 {
+		config.MaxIdleConns = 32
+	}
+	if config.MaxIdleConnsPerHost == 0 {
+		config.MaxIdleConnsPerHost = 16
+	}
+	if config.MaxConnsPerHost == 0 {
+		config.MaxConnsPerHost = 32
+	}
+	if config.IdleConnTimeout == 0 {
+		config.IdleConnTimeout = 90 * time.Second
+	}
+
+	transport := &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		MaxIdleConns:        config.MaxIdleConns,
+		IdleConnTimeout:     config.IdleConnTimeout,
+		DisableCompression:  false,
+		MaxIdleConnsPerHost: config.MaxIdleConnsPerHost,
+		MaxConnsPerHost:     config.MaxConnsPerHost,
+		ForceAttemptHTTP2:   !config.DisableHTTP2,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+	if config.DisableHTTP2 {
+		// A non-nil empty map turns off HTTP/2 negotiation
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	return &http.Client{
+		Timeout:   config.Timeout,
+		Transport: transport,
 	}
 }
 
@@ -111,6 +178,7 @@ type TorrentInfo struct {
 	Size             int64         `json:"size"`
 	Seeds            int           `json:"seeds"`
 	Files            []TorrentFile `json:"files"`
+	CreatedAt        string        `json:"created_at"`
 	UpdatedAt        string        `json:"updated_at"`
 	DownloadFinished bool          `json:"download_finished"`
 }
@@ -121,9 +189,26 @@ type CacheCheck struct {
 }
 
 type CachedFileInfo struct {
-	Name  string `json:"name"`
-	Size  int64  `json:"size"`
-	Index int    `json:"index"`
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+	// Index is the position of the file in the torrent, used by P2P streams
+	Index int `json:"index"`
+	// ID is the TorBox file ID requested by UnrestrictLink. It's only set on files listed by
+	// GetTorrentFiles; the checkcached list has no IDs.
+	ID int `json:"id,omitempty"`
+	// Cached is the per-file cache status of a partially cached torrent, nil when TorBox doesn't report it
+	Cached *bool `json:"cached,omitempty"`
+}
+
+// FileID is the "torrentID,fileID" argument of UnrestrictLink for the file
+func (f CachedFileInfo) FileID(torrentID string) string {
+	return fmt.Sprintf("%s,%d", torrentID, f.ID)
+}
+
+// Available reports whether the file can be streamed; files without a per-file status are
+// considered available, since TorBox only lists them for cached torrents
+func (f CachedFileInfo) Available() bool {
+	return f.Cached == nil || *f.Cached
 }
 
 type SelectedFile struct {
@@ -133,60 +218,63 @@ type SelectedFile struct {
 	Size     int64  `json:"size"`
 }
 
+// Name returns the provider label shown in stream names
+func (c *Client) Name() string {
+	return c.name
+}
+
 // request makes an HTTP request to the TorBox API
-func (c *Client) request(method, path string, params url.Values, formData url.Values) ([]byte, error) {
+func (c *Client) request(ctx context.Context, method, path string, params url.Values, formData url.Values) ([]byte, error) {
 	if c.apiKey == "" {
-		return nil, fmt.Errorf("API key is required")
+		return nil, fmt.Errorf("%w: API key is required", ErrUnauthorized)
 	}
 
-	fullURL := baseURL + path
-	if params != nil && len(params) > 0 {
+	fullURL := c.baseURL + path
+	if len(params) > 0 {
 		fullURL += "?" + params.Encode()
 	}
 	fullURL, _ = url.QueryUnescape(fullURL)
 
-	req, err := http.NewRequest(method, fullURL, strings.NewReader(formData.Encode()))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	req.Header.Set("User-Agent", c.userAgent)
+	var body io.Reader
 	if formData != nil {
-		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		body = strings.NewReader(formData.Encode())
 	}
 
-	resp, err := c.httpClient.Do(req)
+	req, err := http.NewRequestWithContext(ctx, method, fullURL, body)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-	defer func(Body io.ReadCloser) {
-		err := Body.Close()
-		if err != nil {
-
-		}
-	}(resp.Body)
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+	if formData != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
-	}
+	return sendRequest(ctx, c.httpClient, c.limiter, req, c.authorize, classifyTorBoxError)
+}
 
-	return respBody, nil
+// authorize sends the API key as a bearer token
+func (c *Client) authorize(req *http.Request) {
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	req.Header.Set("User-Agent", c.userAgent)
 }
 
 // get makes a GET request
 func (c *Client) get(path string, params url.Values) ([]byte, error) {
-	return c.request(http.MethodGet, path, params, nil)
+	return c.getWithContext(context.Background(), path, params)
+}
+
+// getWithContext makes a GET request bound to ctx
+func (c *Client) getWithContext(ctx context.Context, path string, params url.Values) ([]byte, error) {
+	return c.request(ctx, http.MethodGet, path, params, nil)
 }
 
 // post makes a POST request
 func (c *Client) post(path string, params url.Values, formData url.Values) ([]byte, error) {
-	return c.request(http.MethodPost, path, params, formData)
+	return c.postWithContext(context.Background(), path, params, formData)
+}
+
+// postWithContext makes a POST request bound to ctx
+func (c *Client) postWithContext(ctx context.Context, path string, params url.Values, formData url.Values) ([]byte, error) {
+	return c.request(ctx, http.MethodPost, path, params, formData)
 }
 
 // AccountInfo retrieves account information
@@ -277,8 +365,8 @@ func (c *Client) GetDownloadLink(hash string, fileIndex int) (string, error) {
 		return "", fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
-	if !response.Success {
-		return "", fmt.Errorf("failed to get download link")
+	if !response.Success || response.Data == "" {
+		return "", fmt.Errorf("%w: failed to get download link", ErrTorrentNotReady)
 	}
 
 	return response.Data, nil
@@ -300,13 +388,19 @@ func (c *Client) GetTorrentFiles(hash string) ([]CachedFileInfo, string, error)
 		return nil, "", fmt.Errorf("failed to get torrent info: %w", err)
 	}
 
+	if len(torrentInfo.Files) == 0 && !torrentInfo.DownloadFinished {
+		return nil, torrentID, fmt.Errorf("%w: torrent %s has no files yet", ErrTorrentNotReady, torrentID)
+	}
+
 	// Convert to CachedFileInfo
 	var files []CachedFileInfo
-	for _, file := range torrentInfo.Files {
+	for i, file := range torrentInfo.Files {
+		// TorBox file IDs aren't always sequential, so the position is the index
 		files = append(files, CachedFileInfo{
 			Name:  file.Name,
 			Size:  file.Size,
-			Index: file.ID,
+			Index: i,
+			ID:    file.ID,
 		})
 	}
 
@@ -338,16 +432,20 @@ func (c *Client) UnrestrictLink(fileID string) (string, error) {
 		return "", fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	if response.Data == "" {
+		return "", fmt.Errorf("%w: empty download link for %s", ErrTorrentNotReady, fileID)
+	}
+
 	return response.Data, nil
 }
 
 // CheckCacheSingle checks if a single hash is cached
-func (c *Client) CheckCacheSingle(hash string) ([]CacheCheck, error) {
+func (c *Client) CheckCacheSingle(ctx context.Context, hash string) ([]CacheCheck, error) {
 	params := url.Values{}
 	params.Set("hash", hash)
 	params.Set("format", "list")
 
-	data, err := c.get(cachePath, params)
+	data, err := c.getWithContext(ctx, cachePath, params)
 	if err != nil {
 		return nil, err
 	}
@@ -373,26 +471,36 @@ func (c *Client) generateCacheKey(hashes []string) string {
 
 // CheckCache checks if multiple hashes are cached
 func (c *Client) CheckCache(hashes []string) ([]CacheCheck, error) {
+	return c.CheckCacheWithContext(context.Background(), hashes)
+}
+
+// CheckCacheWithContext checks if multiple hashes are cached, bound to ctx
+func (c *Client) CheckCacheWithContext(ctx context.Context, hashes []string) ([]CacheCheck, error) {
 	// Check cache first if available
-	if c.cache != nil {
+	if c.cache != nil && !types.SkipCache(ctx) {
 		cacheKey := c.generateCacheKey(hashes)
-		if cached, found := c.cache.Get(cacheKey); found {
-			if results, ok := cached.([]CacheCheck); ok {
-				fmt.Printf("📦 Cache hit for TorBox cache check (%d hashes)\n", len(hashes))
-				return results, nil
-			}
+		if results, found := types.CacheGet[[]CacheCheck](c.cache, cacheKey); found {
+			fmt.Printf("📦 Cache hit for TorBox cache check (%d hashes)\n", len(hashes))
+			return results, nil
 		}
 	}
 
+	// Skip hashes TorBox reported as not cached moments ago
+	toCheck := c.filterKnownUncached(ctx, hashes)
+	if len(toCheck) == 0 {
+		fmt.Printf("📦 All %d hashes recently reported uncached, skipping TorBox cache check\n", len(hashes))
+		return nil, nil
+	}
+
 	params := url.Values{}
 	params.Set("format", "list")
-	params.Set("hash", strings.Join(hashes, ","))
+	params.Set("hash", strings.Join(toCheck, ","))
 
 	//body := map[string]interface{}{
 	//	"hashes": hashes,
 	//}
 
-	data, err := c.post(cachePath, params, nil)
+	data, err := c.postWithContext(ctx, cachePath, params, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -406,6 +514,8 @@ func (c *Client) CheckCache(hashes []string) ([]CacheCheck, error) {
 		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	c.rememberUncached(toCheck, response.Data)
+
 	// Cache the results if cache is available
 	if c.cache != nil && c.cacheTTL > 0 {
 		cacheKey := c.generateCacheKey(hashes)
@@ -415,6 +525,43 @@ func (c *Client) CheckCache(hashes []string) ([]CacheCheck, error) {
 	return response.Data, nil
 }
 
+// uncachedKey is the cache key of a hash TorBox reported as not cached
+func uncachedKey(hash string) string {
+	return "torbox_uncached_" + strings.ToLower(hash)
+}
+
+// filterKnownUncached drops the hashes recently reported as not cached
+func (c *Client) filterKnownUncached(ctx context.Context, hashes []string) []string {
+	if c.cache == nil || c.uncachedTTL <= 0 || types.SkipCache(ctx) {
+		return hashes
+	}
+
+	result := make([]string, 0, len(hashes))
+	for _, hash := range hashes {
+		if _, found := c.cache.Get(uncachedKey(hash)); !found {
+			result = append(result, hash)
+		}
+	}
+	return result
+}
+
+// rememberUncached records the checked hashes missing from the TorBox response
+func (c *Client) rememberUncached(checked []string, cached []CacheCheck) {
+	if c.cache == nil || c.uncachedTTL <= 0 {
+		return
+	}
+
+	found := make(map[string]bool, len(cached))
+	for _, item := range cached {
+		found[strings.ToLower(item.Hash)] = true
+	}
+	for _, hash := range checked {
+		if !found[strings.ToLower(hash)] {
+			c.cache.Set(uncachedKey(hash), true, c.uncachedTTL)
+		}
+	}
+}
+
 // AddMagnet adds a magnet link
 func (c *Client) AddMagnet(magnet string) (string, error) {
 	//body := map[string]interface{}{
@@ -434,7 +581,8 @@ func (c *Client) AddMagnet(magnet string) (string, error) {
 	}
 
 	var response struct {
-		Success bool `json:"success"`
+		Success bool   `json:"success"`
+		Error   string `json:"error"`
 		Data    struct {
 			TorrentID int `json:"torrent_id"`
 		} `json:"data"`
@@ -445,6 +593,9 @@ func (c *Client) AddMagnet(magnet string) (string, error) {
 	}
 
 	if !response.Success {
+		if sentinel, ok := torboxErrorCodes[response.Error]; ok {
+			return "", fmt.Errorf("%w: failed to add magnet", sentinel)
+		}
 		return "", fmt.Errorf("failed to add magnet")
 	}
 
@@ -0,0 +1,104 @@
+package debrid
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestTorBoxFilesWithNonSequentialIDs(t *testing.T) {
+	const hash = "0123456789abcdef0123456789abcdef01234567"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/torrents/createtorrent":
+			fmt.Fprint(w, `{"success":true,"data":{"torrent_id":42}}`)
+		case "/torrents/mylist":
+			fmt.Fprint(w, `{"success":true,"data":{"id":42,"hash":"`+hash+`","download_finished":true,"files":[
+				{"id":3,"name":"Show/Show.S01E01.mkv","size":100},
+				{"id":7,"name":"Show/Show.S01E02.mkv","size":200},
+				{"id":12,"name":"Show/Show.S01E03.mkv","size":300}]}}`)
+		case "/torrents/requestdl":
+			q := r.URL.Query()
+			fmt.Fprintf(w, `{"success":true,"data":"https://cdn.torbox.example/%s/%s"}`, q.Get("torrent_id"), q.Get("file_id"))
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{BaseURL: server.URL, APIKey: "key"})
+	files, torrentID, err := client.GetTorrentFiles(hash)
+	if err != nil {
+		t.Fatalf("GetTorrentFiles: %v", err)
+	}
+
+	wantIDs := []int{3, 7, 12}
+	if len(files) != len(wantIDs) {
+		t.Fatalf("got %d files, want %d", len(files), len(wantIDs))
+	}
+	for i, file := range files {
+		if file.Index != i {
+			t.Errorf("%s: Index = %d, want its position %d", file.Name, file.Index, i)
+		}
+		if file.ID != wantIDs[i] {
+			t.Errorf("%s: ID = %d, want %d", file.Name, file.ID, wantIDs[i])
+		}
+	}
+
+	// The second episode is requested by its TorBox ID, not its position
+	link, err := client.UnrestrictLink(files[1].FileID(torrentID))
+	if err != nil {
+		t.Fatalf("UnrestrictLink: %v", err)
+	}
+	if want := "https://cdn.torbox.example/42/7"; link != want {
+		t.Errorf("link = %q, want %q", link, want)
+	}
+}
+
+// BenchmarkTorBoxConnectionReuse runs concurrent link requests and reports the connections
+// opened per request, with the tuned transport and without keep-alives for comparison
+func BenchmarkTorBoxConnectionReuse(b *testing.B) {
+	for _, bench := range []struct {
+		name        string
+		noKeepAlive bool
+	}{
+		{"tuned", false},
+		{"no keep-alive", true},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			var conns atomic.Int64
+			server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"success":true,"data":"https://cdn.torbox.example/42/3"}`)
+			}))
+			server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+				if state == http.StateNew {
+					conns.Add(1)
+				}
+			}
+			server.Start()
+			defer server.Close()
+
+			client := NewClient(Config{BaseURL: server.URL, APIKey: "key"})
+			if bench.noKeepAlive {
+				client.httpClient = &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+			}
+
+			b.SetParallelism(32)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := client.UnrestrictLink("42,3"); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+			b.ReportMetric(float64(conns.Load())/float64(b.N), "conns/op")
+		})
+	}
+}
@@ -5,4 +5,5 @@ go 1.25.5
 require (
 	github.com/IncSW/go-bencode v0.2.2
 	github.com/joho/godotenv v1.5.1
+	gopkg.in/yaml.v3 v3.0.1
 )
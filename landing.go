@@ -0,0 +1,75 @@
+package main
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// Root endpoint modes
+const (
+	RootJSON     = "json"     // {"sdk":"go","addon":name} from the stream package
+	RootRedirect = "redirect" // redirect to /configure
+	RootHTML     = "html"     // landing page with the install link
+)
+
+var landingTemplate = template.Must(template.New("landing").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Name}}</title>
+<style>
+body { font-family: sans-serif; background: #0f0d1a; color: #eee; text-align: center; padding: 4em 1em; }
+a.install { display: inline-block; margin: 1.5em 0; padding: 0.8em 2em; background: #7b5bf5; color: #fff; border-radius: 6px; text-decoration: none; }
+code { color: #aaa; word-break: break-all; }
+</style>
+</head>
+<body>
+<h1>{{.Name}}</h1>
+<p>{{.Description}}</p>
+<a class="install" href="{{.InstallURL}}">Install in Stremio</a>
+<p>Or add this manifest URL manually:<br><code>{{.ManifestURL}}</code></p>
+<p><small>Version {{.Version}}</small></p>
+</body>
+</html>
+`))
+
+// handleRoot serves "/" according to the configured root mode, returning false to fall through to the addon
+func (ta *TorBoxStremioAddon) handleRoot(w http.ResponseWriter, r *http.Request) bool {
+	switch ta.config.RootMode {
+	case RootRedirect:
+		http.Redirect(w, r, "/configure", http.StatusFound)
+		return true
+	case RootHTML:
+		manifest := ta.addon.Manifest()
+		manifestURL := ta.baseURL(r) + "/manifest.json"
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		err := landingTemplate.Execute(w, map[string]string{
+			"Name":        manifest.Name,
+			"Description": manifest.Description,
+			"Version":     manifest.Version,
+			"ManifestURL": manifestURL,
+			"InstallURL":  "stremio://" + strings.SplitN(manifestURL, "://", 2)[1],
+		})
+		if err != nil {
+			log.Printf("⚠️  Failed to render landing page: %v", err)
+		}
+		return true
+	}
+	return false
+}
+
+// baseURL is PUBLIC_URL when set, otherwise the scheme and host the request came in on
+func (ta *TorBoxStremioAddon) baseURL(r *http.Request) string {
+	if ta.config.PublicURL != "" {
+		return ta.config.PublicURL
+	}
+
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
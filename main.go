@@ -1,10 +1,12 @@
 package main
 
 import (
-	"encoding/gob"
+	"crypto/subtle"
 	"net"
 	"os/signal"
+	"regexp"
 	"sort"
+	"strconv"
 	"stremfy/types"
 	"syscall"
 
@@ -13,11 +15,12 @@ import (
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
-	"strconv"
 	"stremfy/caching"
 	"stremfy/debrid"
 	"stremfy/metadata"
@@ -26,6 +29,7 @@ import (
 	"stremfy/torrentManager"
 	"stremfy/utils"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -33,75 +37,161 @@ func init() {
 	// Force pure Go DNS resolver (no CGO)
 	net.DefaultResolver.PreferGo = true
 	net.DefaultResolver.Dial = nil // Use default dialer
-	// Register all types that will be stored as interface{} in cache
-	gob.Register(map[string]interface{}{})
-	gob.Register([]interface{}{})
-	gob.Register([]scrapers.JackettResult{})
-	gob.Register(scrapers.JackettResult{})
-	gob.Register(types.ScrapeResult{})
-	gob.Register([]types.ScrapeResult{})
-	gob.Register([]string{})
-	gob.Register(time.Time{})
 }
 
 type TorBoxStremioAddon struct {
-	addon            *stream.Addon
-	torboxClient     *debrid.Client
+	addon        *stream.Addon
+	debridClient debrid.Provider
+	// cloud is debridClient when its downloads can be listed and polled, nil otherwise
+	cloud            debrid.CloudProvider
 	jackettScraper   *scrapers.JackettScraper
 	metadataProvider *metadata.Provider
 	cache            *caching.Cache
 	backgroundWorker *caching.BackgroundWork
+	downloadWaiters  *downloadWaiters
+	trackers         *utils.TrackerList
+	streamRequests   *requestCounter
+	config           Config
+
+	// background is cancelled on shutdown, stopping the tracker refresh and the work requests leave running
+	background     context.Context
+	stopBackground context.CancelFunc
+	backgroundWork sync.WaitGroup
+	// prewarmSlots bounds the episode pre-warms running at once
+	prewarmSlots chan struct{}
 }
 
-func NewTorBoxStremioAddon(torboxAPIKey, jackettURL, jackettAPIKey string, tmdbAPIKey string, searchTTL, metadataTTL, torboxTTL time.Duration) *TorBoxStremioAddon {
+func NewTorBoxStremioAddon(config Config, cache *caching.Cache, provider debrid.Provider) *TorBoxStremioAddon {
+	cloud, _ := provider.(debrid.CloudProvider)
+	if cloud == nil && config.DownloadsCatalog {
+		log.Printf("⚠️  %s downloads can't be listed, disabling the downloads catalog", provider.Name())
+		config.DownloadsCatalog = false
+	}
+	if cloud == nil && config.CloudCatalog {
+		log.Printf("⚠️  %s library can't be listed, disabling the cloud catalog", provider.Name())
+		config.CloudCatalog = false
+	}
+	if cloud == nil && config.UncachedWait > 0 {
+		log.Printf("⚠️  %s downloads can't be polled, disabling the uncached wait", provider.Name())
+		config.UncachedWait = 0
+	}
+
 	manifest := stream.Manifest{
 		ID:          "com.stremio.stremfy",
-		Version:     "1.0.0",
+		Version:     version,
 		Name:        "Stremfy",
-		Description: "Search torrents via Jackett and stream with TorBox",
-		Resources:   []string{"stream"},
+		Description: "Search torrents via Jackett and stream with " + provider.Name(),
+		Resources:   []stream.Resource{{Name: "stream"}},
 		Types:       []string{"movie", "series"},
 		IDPrefixes:  []string{"tt"},
 		Logo:        "https://torbox.app/logo.png",
 		Background:  "https://torbox.app/background.jpg",
 		BehaviorHints: &stream.BehaviorHints{
+			Adult:                 config.AllowAdult,
 			P2P:                   false,
 			Configurable:          false,
 			ConfigurationRequired: false,
 		},
 	}
 
-	addon := stream.NewAddon(manifest)
+	if config.ReadyCatalog {
+		manifest.Catalogs = []stream.Catalog{
+			{Type: "movie", ID: readyCatalogID, Name: "Ready to Stream"},
+			{Type: "series", ID: readyCatalogID, Name: "Ready to Stream"},
+		}
+	}
+	metaResource := stream.Resource{Name: "meta"}
+	if config.DownloadsCatalog || config.CloudCatalog {
+		// Keep streams to IMDb titles now that the addon has its own IDs
+		manifest.Resources[0] = stream.Resource{
+			Name:       "stream",
+			Types:      append([]string(nil), manifest.Types...),
+			IDPrefixes: append([]string(nil), manifest.IDPrefixes...),
+		}
+		manifest.Types = append(manifest.Types, config.DownloadsType)
+		metaResource.Types = append(metaResource.Types, config.DownloadsType)
+	}
+	if config.CloudCatalog {
+		// Library items are played straight from the TorBox cloud
+		manifest.Resources[0].Types = append(manifest.Resources[0].Types, config.DownloadsType)
+		manifest.Resources[0].IDPrefixes = append(manifest.Resources[0].IDPrefixes, cloudIDPrefix)
+	}
+	if config.DownloadsCatalog {
+		manifest.IDPrefixes = append(manifest.IDPrefixes, downloadIDPrefix)
+		metaResource.IDPrefixes = append(metaResource.IDPrefixes, downloadIDPrefix)
+		manifest.Catalogs = append(manifest.Catalogs, stream.Catalog{
+			Type: config.DownloadsType, ID: downloadsCatalogID, Name: "TorBox Downloads",
+		})
+	}
+	if config.CloudCatalog {
+		manifest.IDPrefixes = append(manifest.IDPrefixes, cloudIDPrefix)
+		metaResource.IDPrefixes = append(metaResource.IDPrefixes, cloudIDPrefix)
+		manifest.Catalogs = append(manifest.Catalogs, stream.Catalog{
+			Type: config.DownloadsType, ID: cloudCatalogID, Name: "TorBox Library",
+			Extra: []stream.ExtraProperty{{Name: "genre", Options: debrid.CloudQualities}},
+		})
+	}
+	if config.SeriesMeta {
+		metaResource.Types = append(metaResource.Types, "series")
+		metaResource.IDPrefixes = append(metaResource.IDPrefixes, "tt")
+	}
+	if len(metaResource.Types) > 0 {
+		manifest.Resources = append(manifest.Resources, metaResource)
+	}
+	if len(manifest.Catalogs) > 0 {
+		manifest.Resources = append(manifest.Resources, stream.Resource{Name: "catalog"})
+	}
 
-	// Initialize caches
-	cache := caching.NewCache()
+	addon := stream.NewAddon(manifest)
 
-	log.Println("✅ Caching system initialized")
-	log.Printf("   - Search cache TTL: %v", searchTTL)
-	log.Printf("   - Metadata cache TTL: %v", metadataTTL)
-	log.Printf("   - TorBox cache check TTL: %v", torboxTTL)
-	log.Printf("   - Hash cache: unlimited")
+	if len(config.VideoExtensions) > 0 {
+		debrid.SetVideoExtensions(config.VideoExtensions)
+	}
+	if len(config.ContainerExtensions) > 0 {
+		debrid.SetContainerExtensions(config.ContainerExtensions)
+	}
 
-	torboxClient := debrid.NewClient(debrid.Config{
-		APIKey:       torboxAPIKey,
-		StoreToCloud: false,
-		Timeout:      30 * time.Second,
-		Cache:        cache,
-		CacheTTL:     torboxTTL,
+	jackettScraper := scrapers.NewJackettScraper(scrapers.JackettConfig{
+		URL:        config.JackettURL,
+		APIKey:     config.JackettAPIKey,
+		API:        config.IndexerType,
+		Cache:      cache,
+		SearchTTL:  config.SearchTTL,
+		SizeRanges: config.SizeRanges,
+
+		BlockedKeywords: config.BlockedKeywords,
+		BlockedGroups:   config.BlockedGroups,
+		MaxResults:      config.MaxScrapeResults,
+		AllowAdult:      config.AllowAdult,
+		Instances:       config.JackettInstances,
+		PackWords:       config.SeriesPackWords,
+		UnpaddedQueries: config.UnpaddedQueries,
+
+		StripReleaseNoise: config.StripReleaseNoise,
+		Timeout:           config.JackettTimeout,
+		EmptyRetryDelay:   config.SearchEmptyRetryDelay,
 	})
 
-	jackettScraper := scrapers.NewJackettScraper(nil, jackettURL, jackettAPIKey, cache, searchTTL)
-
 	var metadataProvider *metadata.Provider
-	metadataProvider = metadata.NewMetadataProvider(tmdbAPIKey, metadataTTL)
+	metadataProvider = metadata.NewMetadataProvider(config.TMDBAPIKey, config.MetadataTTL, config.CacheCleanupInterval)
+	metadataProvider.SetAPIURL(config.TMDBAPIURL)
 	log.Println("✅ TMDB metadata provider initialized")
 
+	background, stopBackground := context.WithCancel(context.Background())
 	ta := &TorBoxStremioAddon{
 		addon:            addon,
-		torboxClient:     torboxClient,
+		debridClient:     provider,
+		cloud:            cloud,
 		jackettScraper:   jackettScraper,
 		metadataProvider: metadataProvider,
 		cache:            cache,
+		downloadWaiters:  newDownloadWaiters(),
+		trackers:         utils.NewTrackerList(config.FallbackTrackers),
+		streamRequests:   &requestCounter{limit: int64(config.MaxActiveStreams)},
+		config:           config,
+		background:       background,
+		stopBackground:   stopBackground,
+		prewarmSlots:     make(chan struct{}, max(config.UnrestrictConcurrency, 1)),
 	}
 
 	// Initialize background worker with injected dependencies
@@ -110,28 +200,476 @@ func NewTorBoxStremioAddon(torboxAPIKey, jackettURL, jackettAPIKey string, tmdbA
 		func(ctx context.Context, req types.ScrapeRequest) ([]types.ScrapeResult, error) {
 			return ta.searchTorrents(ctx, req)
 		},
+		ta.cachedHashes,
+		cache,
 		ta.metadataProvider,
+		caching.BackgroundConfig{
+			UserDedupWindow:     config.PrefetchUserDedupWindow,
+			TrendingDedupWindow: config.PrefetchTrendingDedupWindow,
+			PacksFirst:          config.PrefetchPacksFirst,
+			PacksOnly:           config.PrefetchPacksOnly,
+			Concurrency:         config.PrefetchConcurrency,
+			MaxSearches:         config.PrefetchMaxSearches,
+			MovieQualities:      config.PrefetchMovieQualities,
+			Trending:            config.PrefetchTrending,
+			UserPrefetch:        config.PrefetchOnWatch,
+			TrendingMinAge:      config.PrefetchTrendingMinAge,
+		},
 	)
 
+	if config.TrackersURL != "" {
+		ta.trackers.StartRefresh(ta.background, config.TrackersURL, config.TrackersRefresh)
+	}
+
 	addon.SetStreamHandler(ta.handleStream)
+	if config.ReadyCatalog || config.DownloadsCatalog || config.CloudCatalog {
+		addon.SetCatalogHandler(ta.handleCatalog)
+	}
+	if config.DownloadsCatalog || config.CloudCatalog || config.SeriesMeta {
+		addon.SetMetaHandler(ta.handleMeta)
+	}
 
 	return ta
 }
 
+// newCache creates the cache shared by the scrapers, the debrid provider and the metadata provider
+func newCache(config Config) *caching.Cache {
+	cache := caching.NewCache(caching.Config{
+		CleanupInterval: config.CacheCleanupInterval,
+		SaveInterval:    config.CacheSaveInterval,
+		MemoryOnly:      config.CacheMemoryOnly,
+	})
+
+	log.Println("✅ Caching system initialized")
+	log.Printf("   - Search cache TTL: %v", config.SearchTTL)
+	log.Printf("   - Metadata cache TTL: %v", config.MetadataTTL)
+	log.Printf("   - Debrid cache check TTL: %v", config.TorBoxTTL)
+	log.Printf("   - Hash cache: unlimited")
+
+	return cache
+}
+
+// newDebridProvider creates the client of the configured debrid service
+func newDebridProvider(config Config, cache *caching.Cache) debrid.Provider {
+	switch config.DebridService {
+	case debridRealDebrid:
+		log.Println("✅ Streaming through Real-Debrid")
+		return debrid.NewRealDebridClient(debrid.Config{
+			BaseURL:  config.RealDebridAPIURL,
+			Name:     config.StreamName,
+			APIKey:   config.RealDebridAPIKey,
+			Timeout:  config.DebridTimeout,
+			Cache:    cache,
+			CacheTTL: config.TorBoxTTL,
+		})
+	case debridAllDebrid:
+		log.Println("✅ Streaming through AllDebrid")
+		return debrid.NewAllDebridClient(debrid.Config{
+			BaseURL:  config.AllDebridAPIURL,
+			Name:     config.StreamName,
+			APIKey:   config.AllDebridAPIKey,
+			Timeout:  config.DebridTimeout,
+			Cache:    cache,
+			CacheTTL: config.TorBoxTTL,
+		})
+	}
+
+	return debrid.NewClient(debrid.Config{
+		BaseURL:      config.TorBoxAPIURL,
+		Name:         config.StreamName,
+		APIKey:       config.TorBoxAPIKey,
+		StoreToCloud: false,
+		Timeout:      config.DebridTimeout,
+		Cache:        cache,
+		CacheTTL:     config.TorBoxTTL,
+		UncachedTTL:  config.TorBoxUncachedTTL,
+
+		MaxIdleConns:        config.TorBoxMaxIdleConns,
+		MaxIdleConnsPerHost: config.TorBoxMaxIdleConns,
+		MaxConnsPerHost:     config.TorBoxMaxConnsPerHost,
+		DisableHTTP2:        config.TorBoxDisableHTTP2,
+		RateLimit:           config.TorBoxRateLimit,
+		RateBurst:           config.TorBoxRateBurst,
+	})
+}
+
+// readyCatalogID is the catalog listing titles prefetched and cached on TorBox
+const readyCatalogID = "stremfy-ready"
+
+func (ta *TorBoxStremioAddon) handleCatalog(catalogType, catalogID string, extra map[string]string) (*stream.CatalogResponse, error) {
+	switch {
+	case catalogID == downloadsCatalogID && ta.config.DownloadsCatalog:
+		return ta.handleDownloadsCatalog()
+	case catalogID == cloudCatalogID && ta.config.CloudCatalog:
+		return ta.handleCloudCatalog(extra["genre"])
+	case catalogID != readyCatalogID || !ta.config.ReadyCatalog:
+		return nil, fmt.Errorf("unknown catalog: %s", catalogID)
+	}
+
+	metas := []stream.MetaItem{}
+	for _, title := range ta.backgroundWorker.ReadyTitles(catalogType) {
+		metas = append(metas, stream.MetaItem{
+			ID:          title.IMDbID,
+			Type:        title.Type,
+			Name:        title.Title,
+			Poster:      title.Poster,
+			PosterShape: ta.config.ReadyPosterShape,
+			Background:  title.Background,
+			ReleaseInfo: title.Year,
+		})
+	}
+
+	log.Printf("🌟 Catalog %s/%s: %d ready titles", catalogType, catalogID, len(metas))
+	return &stream.CatalogResponse{Metas: metas}, nil
+}
+
+// The downloads catalog lists in-progress TorBox downloads as DownloadsType items with their own IDs
+const (
+	downloadsCatalogID = "stremfy-downloads"
+	downloadIDPrefix   = "stremfy-dl:"
+)
+
+func (ta *TorBoxStremioAddon) handleDownloadsCatalog() (*stream.CatalogResponse, error) {
+	torrents, err := ta.cloud.UserCloud("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list TorBox downloads: %w", err)
+	}
+
+	active := debrid.ActiveDownloads(torrents)
+	debrid.SortCloudByDateAdded(active)
+
+	metas := []stream.MetaItem{}
+	for _, torrent := range active {
+		metas = append(metas, ta.downloadMeta(torrent))
+	}
+
+	log.Printf("📥 Downloads catalog: %d in progress", len(metas))
+	return &stream.CatalogResponse{Metas: metas}, nil
+}
+
+// The cloud catalog lists the finished TorBox downloads, with IDs derived from the torrent hash
+const (
+	cloudCatalogID = "stremfy-cloud"
+	cloudIDPrefix  = "stremfy-cloud:"
+)
+
+// handleCloudCatalog lists the TorBox library newest first, optionally only one quality
+func (ta *TorBoxStremioAddon) handleCloudCatalog(quality string) (*stream.CatalogResponse, error) {
+	torrents, err := ta.cloud.UserCloud("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list TorBox library: %w", err)
+	}
+
+	library := debrid.FilterCloudByQuality(debrid.FinishedDownloads(torrents), quality)
+	debrid.SortCloudByDateAdded(library)
+
+	metas := []stream.MetaItem{}
+	for _, torrent := range library {
+		metas = append(metas, ta.cloudMeta(torrent))
+	}
+
+	log.Printf("☁️  Cloud catalog: %d torrents", len(metas))
+	return &stream.CatalogResponse{Metas: metas}, nil
+}
+
+func (ta *TorBoxStremioAddon) cloudMeta(torrent debrid.TorrentInfo) stream.MetaItem {
+	description := fmt.Sprintf("%s • %d files", debrid.FormatBytes(torrent.Size), len(torrent.Files))
+	if quality := utils.ExtractQuality(torrent.Name); quality != "" {
+		description = quality + " • " + description
+	}
+
+	artwork := ta.cloudArtwork(torrent)
+	return stream.MetaItem{
+		ID:          cloudIDPrefix + strings.ToLower(torrent.Hash),
+		Type:        ta.config.DownloadsType,
+		Name:        torrent.Name,
+		Poster:      artwork.Poster,
+		PosterShape: ta.config.DownloadsPosterShape,
+		Background:  artwork.Background,
+		Description: description,
+	}
+}
+
+// seriesNamePattern tells season packs and episodes apart from movies in release names
+var seriesNamePattern = regexp.MustCompile(`(?i)\b(s\d{1,2}(e\d{1,3})?|\d{1,2}x\d{2,3}|season|temporada)\b`)
+
+func cloudArtworkKey(hash string) string {
+	return "cloud_artwork_" + strings.ToLower(hash)
+}
+
+// cloudArtwork finds the TMDB poster and background of a library torrent from its name,
+// cached by hash, including misses so unknown names aren't searched again
+func (ta *TorBoxStremioAddon) cloudArtwork(torrent debrid.TorrentInfo) metadata.Artwork {
+	key := cloudArtworkKey(torrent.Hash)
+	if artwork, found := types.CacheGet[metadata.Artwork](ta.cache, key); found {
+		return artwork
+	}
+
+	title, year := utils.ParseTorrentName(torrent.Name)
+	if title == "" {
+		return metadata.Artwork{}
+	}
+	mediaType := "movie"
+	if seriesNamePattern.MatchString(torrent.Name) {
+		mediaType = "series"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ta.config.StreamTimeout)
+	defer cancel()
+	results, err := ta.metadataProvider.SearchByTitle(ctx, title, mediaType)
+	if err != nil {
+		// Not cached, the next catalog load retries
+		log.Printf("⚠️  Failed to search artwork for %s: %v", torrent.Name, err)
+		return metadata.Artwork{}
+	}
+
+	var artwork metadata.Artwork
+	if best, ok := bestSearchResult(results, torrent.Name, year); ok {
+		artwork = best.Artwork
+	}
+	ta.cache.Set(key, artwork, ta.config.MetadataTTL)
+	return artwork
+}
+
+// bestSearchResult picks the first result matching the release name, preferring one of the same year
+func bestSearchResult(results []metadata.SearchResult, name, year string) (metadata.SearchResult, bool) {
+	matcher := scrapers.NewTitleMatcher(85)
+
+	var best metadata.SearchResult
+	found := false
+	for _, result := range results {
+		if !matcher.Matches(result.Title, name) {
+			continue
+		}
+		if year == "" || result.Year == year {
+			return result, true
+		}
+		if !found {
+			best, found = result, true
+		}
+	}
+	return best, found
+}
+
+// cloudTorrent finds a torrent of the TorBox library by hash
+func (ta *TorBoxStremioAddon) cloudTorrent(hash string) (*debrid.TorrentInfo, error) {
+	torrents, err := ta.cloud.UserCloud("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list TorBox library: %w", err)
+	}
+	for i := range torrents {
+		if strings.EqualFold(torrents[i].Hash, hash) {
+			return &torrents[i], nil
+		}
+	}
+	return nil, fmt.Errorf("torrent %s is not in the TorBox library", hash)
+}
+
+// cloudStreams lists a stream for every video file of a TorBox library torrent
+func (ta *TorBoxStremioAddon) cloudStreams(hash string, req stream.StreamRequest) (*stream.StreamResponse, error) {
+	torrent, err := ta.cloudTorrent(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	source := types.ScrapeResult{Title: torrent.Name, InfoHash: strings.ToLower(torrent.Hash), Size: torrent.Size}
+	torrentID := strconv.Itoa(torrent.ID)
+
+	streams := []stream.Stream{}
+	for i, f := range torrent.Files {
+		if !debrid.IsVideoFile(f.Name) {
+			continue
+		}
+		file := debrid.CachedFileInfo{Name: f.Name, Size: f.Size, Index: i, ID: f.ID}
+
+		link := ""
+		if ta.config.LazyUnrestrict {
+			link = ta.resolveURL(source.InfoHash, torrentID, file)
+		} else if link, err = ta.unrestrictCached(file.FileID(torrentID)); err != nil {
+			log.Printf("⚠️  Failed to get download link for %s: %v", file.Name, err)
+			continue
+		}
+		streams = append(streams, ta.buildURLStream(source, file, link, req))
+	}
+
+	log.Printf("☁️  Library torrent %s: %d streams", torrent.Name, len(streams))
+	return &stream.StreamResponse{Streams: streams}, nil
+}
+
+func (ta *TorBoxStremioAddon) handleMeta(metaType, id string) (*stream.MetaResponse, error) {
+	if metaType == "series" && ta.config.SeriesMeta && strings.HasPrefix(id, "tt") {
+		meta, err := ta.seriesMeta(context.Background(), id)
+		if err != nil {
+			return nil, err
+		}
+		return &stream.MetaResponse{Meta: meta}, nil
+	}
+
+	if hash, ok := strings.CutPrefix(id, cloudIDPrefix); ok && ta.config.CloudCatalog {
+		torrent, err := ta.cloudTorrent(hash)
+		if err != nil {
+			return nil, err
+		}
+		return &stream.MetaResponse{Meta: ta.cloudMeta(*torrent)}, nil
+	}
+
+	torrentID, ok := strings.CutPrefix(id, downloadIDPrefix)
+	if !ok || ta.cloud == nil {
+		return nil, fmt.Errorf("unknown meta: %s", id)
+	}
+
+	// Fetched fresh on every open so the progress is current
+	torrent, err := ta.cloud.TorrentInfo(torrentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get TorBox download %s: %w", torrentID, err)
+	}
+
+	return &stream.MetaResponse{Meta: ta.downloadMeta(*torrent)}, nil
+}
+
+// seriesMeta builds a series meta from TMDB, fetching each season's episodes in one call
+func (ta *TorBoxStremioAddon) seriesMeta(ctx context.Context, imdbID string) (stream.MetaItem, error) {
+	meta, err := ta.metadataProvider.GetMetadataFromTMDB(imdbID)
+	if err != nil {
+		return stream.MetaItem{}, fmt.Errorf("failed to get metadata for %s: %w", imdbID, err)
+	}
+	show, err := ta.metadataProvider.GetTVShowDetails(meta.ID)
+	if err != nil {
+		return stream.MetaItem{}, fmt.Errorf("failed to get show details for %s: %w", imdbID, err)
+	}
+
+	item := stream.MetaItem{
+		ID:          imdbID,
+		Type:        "series",
+		Name:        show.Name,
+		Poster:      metadata.ImageURL(show.PosterPath, "w500"),
+		Background:  metadata.ImageURL(show.BackdropPath, "original"),
+		Description: show.Overview,
+		ReleaseInfo: show.Year,
+	}
+	if show.VoteAverage > 0 {
+		item.IMDbRating = fmt.Sprintf("%.1f", show.VoteAverage)
+	}
+
+	for _, summary := range show.Seasons {
+		season, err := ta.metadataProvider.GetSeasonDetails(ctx, meta.ID, summary.SeasonNumber)
+		if err != nil {
+			log.Printf("⚠️  Failed to get season %d of %s: %v", summary.SeasonNumber, imdbID, err)
+			continue
+		}
+
+		for _, episode := range season.Episodes {
+			video := stream.Video{
+				ID:        fmt.Sprintf("%s:%d:%d", imdbID, episode.SeasonNumber, episode.EpisodeNumber),
+				Title:     episode.Name,
+				Season:    episode.SeasonNumber,
+				Episode:   episode.EpisodeNumber,
+				Overview:  episode.Overview,
+				Thumbnail: metadata.ImageURL(episode.StillPath, "w300"),
+			}
+			if released, err := time.Parse("2006-01-02", episode.AirDate); err == nil {
+				video.Released = released.Format(time.RFC3339)
+			}
+			item.Videos = append(item.Videos, video)
+		}
+	}
+
+	return item, nil
+}
+
+// downloadMeta renders a TorBox download with its progress in the description
+func (ta *TorBoxStremioAddon) downloadMeta(torrent debrid.TorrentInfo) stream.MetaItem {
+	description := fmt.Sprintf("%.1f%% • %s/s • %s of %s • %s",
+		debrid.DownloadProgress(torrent),
+		debrid.FormatBytes(int64(torrent.DownloadSpeed)),
+		debrid.FormatBytes(torrent.TotalDownloaded),
+		debrid.FormatBytes(torrent.Size),
+		torrent.DownloadState)
+
+	return stream.MetaItem{
+		ID:          fmt.Sprintf("%s%d", downloadIDPrefix, torrent.ID),
+		Type:        ta.config.DownloadsType,
+		Name:        torrent.Name,
+		PosterShape: ta.config.DownloadsPosterShape,
+		Description: description,
+	}
+}
+
 func (ta *TorBoxStremioAddon) handleStream(req stream.StreamRequest) (*stream.StreamResponse, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), ta.config.StreamTimeout)
 	defer cancel()
 
+	if !ta.streamRequests.acquire() {
+		return &stream.StreamResponse{Streams: []stream.Stream{}}, nil
+	}
+	defer ta.streamRequests.release()
+
+	if hash, ok := strings.CutPrefix(req.ID, cloudIDPrefix); ok && ta.config.CloudCatalog {
+		return ta.cloudStreams(hash, req)
+	}
+
 	startTime := time.Now()
+	phases := newRequestPhases()
+	defer ta.logSlowRequest(req, phases)
+
+	// With JSON logs the request lifecycle is covered by the access log line
+	jsonLogs := ta.config.LogFormat == "json"
+	if !jsonLogs {
+		log.Printf("📺 Stream request: %s", req.String())
+	}
+
+	if req.Refresh {
+		if !ta.refreshAllowed(req.AdminToken) {
+			log.Printf("🚫 Ignoring refresh for %s: missing or invalid admin token", req.String())
+		} else {
+			log.Printf("🔄 Refresh requested for %s, bypassing caches", req.String())
+			ctx = types.WithSkipCache(ctx)
+		}
+	}
+
+	// Reuse season packs prefetch already found cached on TorBox
+	if req.IsSeries() && !types.SkipCache(ctx) {
+		stopTorBox := phases.track("torbox")
+		streams := ta.streamsFromKnownPacks(ctx, req)
+		stopTorBox()
+		if len(streams) > 0 {
+			log.Printf("📦 Returning %d streams from known cached season packs", len(streams))
+			stopMetadata := phases.track("metadata")
+			ta.addBitrateInfo(ctx, streams, req)
+			stopMetadata()
+			ta.sortStreams(streams, ta.sortMode(req))
+			return &stream.StreamResponse{Streams: streams}, nil
+		}
+	}
+
+	stopMetadata := phases.track("metadata")
 
-	log.Printf("📺 Stream request: %s", req.String())
+	// Anime releases often number episodes across seasons
+	if ta.config.AnimeAbsoluteEpisodes && req.IsSeries() && req.Season > 1 {
+		if absolute, err := ta.metadataProvider.GetAbsoluteEpisode(req.ID, req.Season, req.Episode); err == nil {
+			log.Printf("🔢 %s is absolute episode %d", req.String(), absolute)
+			req.AbsoluteEpisode = absolute
+		} else {
+			log.Printf("⚠️  No absolute episode for %s: %v", req.String(), err)
+		}
+	}
 
 	// Build search query
 	searchQuery := ta.buildSearchQuery(req)
-
-	// Search torrents
-	torrents, err := ta.searchTorrents(ctx, searchQuery)
-	if err != nil {
+	stopMetadata()
+
+	// Search torrents, leaving part of the budget to check TorBox with what was found
+	stopJackett := phases.track("jackett")
+	searchCtx, cancelSearch := context.WithTimeout(ctx, ta.config.SearchTimeout)
+	torrents, err := ta.searchTorrents(searchCtx, searchQuery)
+	cancelSearch()
+	stopJackett()
+	if errors.Is(err, context.DeadlineExceeded) && len(torrents) > 0 {
+		log.Printf("⏱ Search cut short, continuing with %d torrents found so far", len(torrents))
+	} else if errors.Is(err, scrapers.ErrIndexerUnavailable) {
+		log.Printf("❌ Jackett is unavailable, no streams for %s (check JACKETT_URL/JACKETT_API_KEY): %v", req.String(), err)
+		return &stream.StreamResponse{Streams: []stream.Stream{}}, nil
+	} else if err != nil {
 		log.Printf("❌ Error searching torrents: %v", err)
 		return &stream.StreamResponse{Streams: []stream.Stream{}}, nil
 	}
@@ -142,21 +680,43 @@ func (ta *TorBoxStremioAddon) handleStream(req stream.StreamRequest) (*stream.St
 		return &stream.StreamResponse{Streams: []stream.Stream{}}, nil
 	}
 
-	// Extract hashes and check TorBox cache
-	streams, err := ta.checkCacheAndBuildStreams(torrents, req)
-	if err != nil {
-		log.Printf("❌ Error checking cache: %v", err)
+	// Extract hashes and check the debrid cache
+	stopTorBox := phases.track("torbox")
+	streams, err := ta.checkCacheAndBuildStreams(ctx, torrents, req)
+	stopTorBox()
+	if errors.Is(err, context.DeadlineExceeded) && len(streams) > 0 {
+		log.Printf("⏱ Deadline reached, returning %d partial streams", len(streams))
+	} else if err != nil {
+		switch {
+		case errors.Is(err, debrid.ErrUnauthorized):
+			log.Printf("❌ %s rejected the API key: %v", ta.debridClient.Name(), err)
+		case errors.Is(err, debrid.ErrRateLimited):
+			log.Printf("⏳ %s rate limit reached: %v", ta.debridClient.Name(), err)
+		default:
+			log.Printf("❌ Error checking cache: %v", err)
+		}
 		return &stream.StreamResponse{Streams: []stream.Stream{}}, nil
 	}
 
-	endTime := time.Since(startTime)
-	log.Printf("⏱ Took %d seconds to fetch!\n", int(endTime.Seconds()))
+	// Nothing cached: optionally add the best torrent to TorBox and wait for it
+	if len(streams) == 0 && ta.config.UncachedWait > 0 {
+		stopWait := phases.track("uncached wait")
+		streams = ta.waitForUncached(ctx, torrents, req)
+		stopWait()
+	}
 
-	log.Printf("✅ Returning %d cached streams", len(streams))
+	stopMetadata = phases.track("metadata")
+	ta.addBitrateInfo(ctx, streams, req)
+	stopMetadata()
 
-	sort.Slice(streams, func(i, j int) bool {
-		return streams[i].BehaviorHints.VideoSize > streams[j].BehaviorHints.VideoSize
-	})
+	if !jsonLogs {
+		endTime := time.Since(startTime)
+		log.Printf("⏱ Took %d seconds to fetch!\n", int(endTime.Seconds()))
+
+		log.Printf("✅ Returning %d cached streams", len(streams))
+	}
+
+	ta.sortStreams(streams, ta.sortMode(req))
 
 	ta.backgroundWorker.UserBackgroundTask(req)
 
@@ -165,6 +725,289 @@ func (ta *TorBoxStremioAddon) handleStream(req stream.StreamRequest) (*stream.St
 	}, nil
 }
 
+// uncachedPollInterval is how often an added torrent is checked while waiting for it;
+// with the TorBox webhook enabled polling is only a fallback for missed notifications
+const (
+	uncachedPollInterval        = 2 * time.Second
+	uncachedWebhookPollInterval = 30 * time.Second
+)
+
+// waitForUncached adds the best-seeded torrent to TorBox and waits for it for up to UncachedWait
+// (bounded by ctx), returning its streams if the download finishes in time. The torrent is
+// re-checked on every TorBox webhook notification and on a poll interval.
+func (ta *TorBoxStremioAddon) waitForUncached(ctx context.Context, torrents []types.ScrapeResult, req stream.StreamRequest) []stream.Stream {
+	var best *types.ScrapeResult
+	for i := range torrents {
+		if torrents[i].InfoHash == "" {
+			continue
+		}
+		if best == nil || seedersOf(torrents[i]) > seedersOf(*best) {
+			best = &torrents[i]
+		}
+	}
+	if best == nil {
+		return nil
+	}
+
+	torrentID, err := ta.debridClient.AddMagnet("magnet:?xt=urn:btih:" + best.InfoHash)
+	if err != nil {
+		log.Printf("⚠️  Failed to add uncached torrent %s: %v", best.Title, err)
+		return nil
+	}
+	log.Printf("⏳ Added uncached torrent %s (ID: %s), waiting up to %v", best.Title, torrentID, ta.config.UncachedWait)
+
+	waitCtx, cancel := context.WithTimeout(ctx, ta.config.UncachedWait)
+	defer cancel()
+
+	pollInterval := uncachedPollInterval
+	if ta.config.WebhookSecret != "" {
+		pollInterval = uncachedWebhookPollInterval
+	}
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	notified, unregister := ta.downloadWaiters.wait(torrentID)
+	defer unregister()
+
+	for {
+		select {
+		case <-waitCtx.Done():
+			log.Printf("⏳ %s not ready in time", best.Title)
+			return nil
+		case <-ticker.C:
+		case <-notified:
+		}
+
+		info, err := ta.cloud.TorrentInfo(torrentID)
+		if err != nil {
+			log.Printf("⚠️  Failed to poll torrent %s: %v", torrentID, err)
+			return nil
+		}
+		if !info.DownloadFinished {
+			continue
+		}
+
+		log.Printf("✅ %s finished downloading", best.Title)
+		// The cache check result from before the download is stale
+		streams, err := ta.checkCacheAndBuildStreams(types.WithSkipCache(ctx), []types.ScrapeResult{*best}, req)
+		if err != nil {
+			log.Printf("⚠️  Failed to build streams for %s: %v", best.Title, err)
+		}
+		return streams
+	}
+}
+
+// seedersOf returns the seeders of a result, or 0 when unknown
+func seedersOf(torrent types.ScrapeResult) int {
+	if torrent.Seeders == nil {
+		return 0
+	}
+	return *torrent.Seeders
+}
+
+// addBitrateInfo appends the estimated bitrate to each stream description when the runtime is known
+func (ta *TorBoxStremioAddon) addBitrateInfo(ctx context.Context, streams []stream.Stream, req stream.StreamRequest) {
+	if len(streams) == 0 || ta.metadataProvider == nil {
+		return
+	}
+
+	runtime, err := ta.metadataProvider.GetRuntime(ctx, req.ID, req.Season, req.Episode)
+	if err != nil {
+		log.Printf("⚠️  No runtime for %s, omitting bitrate: %v", req.String(), err)
+		return
+	}
+
+	for i := range streams {
+		if streams[i].BehaviorHints == nil {
+			continue
+		}
+		if bitrate := utils.EstimateBitrate(streams[i].BehaviorHints.VideoSize, runtime); bitrate > 0 {
+			if ta.config.PlainTitles {
+				streams[i].Description += fmt.Sprintf(" | %.1f Mbps", bitrate)
+			} else {
+				streams[i].Description += fmt.Sprintf(" 📶 %.1f Mbps", bitrate)
+			}
+		}
+	}
+}
+
+// cachedHashes returns the hashes TorBox reports as cached
+func (ta *TorBoxStremioAddon) cachedHashes(hashes []string) ([]string, error) {
+	cached, err := ta.debridClient.CheckCache(hashes)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for _, item := range cached {
+		if item.Hash != "" {
+			result = append(result, item.Hash)
+		}
+	}
+	return result, nil
+}
+
+// streamsFromKnownPacks builds streams from cached season packs stored by the prefetcher
+func (ta *TorBoxStremioAddon) streamsFromKnownPacks(ctx context.Context, req stream.StreamRequest) []stream.Stream {
+	packs, found := types.CacheGet[[]types.ScrapeResult](ta.cache, caching.SeasonPackCacheKey(req.ID, req.Season))
+	if !found || len(packs) == 0 {
+		return nil
+	}
+
+	streams, err := ta.checkCacheAndBuildStreams(ctx, packs, req)
+	if err != nil {
+		log.Printf("⚠️  Failed to use known season packs for %s: %v", req.String(), err)
+		return nil
+	}
+	return streams
+}
+
+// refreshAllowed checks the admin token that lets a request bypass the caches
+func (ta *TorBoxStremioAddon) refreshAllowed(token string) bool {
+	if ta.config.AdminToken == "" || token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(ta.config.AdminToken)) == 1
+}
+
+// streamAudio detects the audio of a stream from the release title on the first line of its description
+func streamAudio(s stream.Stream) string {
+	releaseTitle, _, _ := strings.Cut(s.Description, "\n")
+	return utils.ExtractAudio(releaseTitle)
+}
+
+// sortMode returns the sort mode asked for with ?sort=, falling back to SortMode when missing or unknown
+func (ta *TorBoxStremioAddon) sortMode(req stream.StreamRequest) string {
+	if req.Sort == "" {
+		return ta.config.SortMode
+	}
+	if !validSortMode(req.Sort) {
+		log.Printf("⚠️  Unknown sort %q for %s, using %q", req.Sort, req.String(), ta.config.SortMode)
+		return ta.config.SortMode
+	}
+	return req.Sort
+}
+
+// sortStreams orders streams by size, or first by seeders or quality for those sort modes, optionally
+// keeping direct-URL (cached) and dual-audio streams on top
+func (ta *TorBoxStremioAddon) sortStreams(streams []stream.Stream, mode string) {
+	sort.SliceStable(streams, func(i, j int) bool {
+		if ta.config.CachedFirst {
+			cachedI, cachedJ := streams[i].URL != "", streams[j].URL != ""
+			if cachedI != cachedJ {
+				return cachedI
+			}
+		}
+		if ta.config.PreferDualAudio {
+			dualI, dualJ := streamAudio(streams[i]) == utils.AudioDual, streamAudio(streams[j]) == utils.AudioDual
+			if dualI != dualJ {
+				return dualI
+			}
+		}
+		switch mode {
+		case SortSeeders:
+			if seedersI, seedersJ := streams[i].Seeders, streams[j].Seeders; seedersI != seedersJ {
+				return seedersI > seedersJ
+			}
+		case SortQuality:
+			if rankI, rankJ := utils.QualityRank(streams[i].Quality), utils.QualityRank(streams[j].Quality); rankI != rankJ {
+				return rankI > rankJ
+			}
+		}
+		return streams[i].BehaviorHints.VideoSize > streams[j].BehaviorHints.VideoSize
+	})
+
+	if ta.config.PreferredCodec != "" {
+		preferCodec(streams, ta.config.PreferredCodec)
+	}
+	if mode == SortInterleaved {
+		interleaveQualities(streams)
+	}
+	if ta.config.BingeGroupLimit > 0 {
+		limitBingeGroups(streams, ta.config.BingeGroupLimit)
+	}
+}
+
+// limitBingeGroups keeps the binge group on only the first limit streams sharing it, so
+// auto-play picks the next episode from a small set of the best sorted streams
+func limitBingeGroups(streams []stream.Stream, limit int) {
+	counts := make(map[string]int)
+	for i := range streams {
+		hints := streams[i].BehaviorHints
+		if hints == nil || hints.BingeGroup == "" {
+			continue
+		}
+		counts[hints.BingeGroup]++
+		if counts[hints.BingeGroup] > limit {
+			hints.BingeGroup = ""
+		}
+	}
+}
+
+// resolutionPattern finds the resolution tag ending the name part of a release title
+var resolutionPattern = regexp.MustCompile(`(?i)\b(2160p|1080p|720p|480p|4k|uhd)\b`)
+var nonAlphanumeric = regexp.MustCompile(`[^a-z0-9]+`)
+
+// encodeKey identifies the encodes of the same release and resolution: the normalised
+// title up to the resolution plus the resolution. Empty when the resolution is unknown.
+func encodeKey(releaseTitle string) string {
+	loc := resolutionPattern.FindStringIndex(releaseTitle)
+	if loc == nil {
+		return ""
+	}
+	name := strings.TrimSpace(nonAlphanumeric.ReplaceAllString(strings.ToLower(releaseTitle[:loc[0]]), " "))
+	return name + "|" + utils.ExtractQuality(releaseTitle)
+}
+
+// preferCodec moves the encodes in the preferred codec (e.g. H265) above the other encodes of
+// the same title and resolution, keeping both and the order of everything else
+func preferCodec(streams []stream.Stream, codec string) {
+	keys := make([]string, len(streams))
+	lastPreferred := make(map[string]int)
+	for i, s := range streams {
+		releaseTitle, _, _ := strings.Cut(s.Description, "\n")
+		keys[i] = encodeKey(releaseTitle)
+		if keys[i] != "" && utils.ExtractCodec(releaseTitle) == codec {
+			lastPreferred[keys[i]] = i
+		}
+	}
+
+	deferred := make(map[string][]stream.Stream)
+	result := make([]stream.Stream, 0, len(streams))
+	for i, s := range streams {
+		key := keys[i]
+		last, found := lastPreferred[key]
+		releaseTitle, _, _ := strings.Cut(s.Description, "\n")
+		if found && i < last && utils.ExtractCodec(releaseTitle) != codec {
+			deferred[key] = append(deferred[key], s)
+			continue
+		}
+		result = append(result, s)
+		if found && i == last {
+			result = append(result, deferred[key]...)
+		}
+	}
+	copy(streams, result)
+}
+
+// interleaveQualities moves the best stream of every quality tier to the top, keeping
+// the sorted order otherwise, so a 720p option isn't buried under a page of 4K remuxes
+func interleaveQualities(streams []stream.Stream) {
+	seen := make(map[string]bool)
+	var heads, rest []stream.Stream
+	for _, s := range streams {
+		releaseTitle, _, _ := strings.Cut(s.Description, "\n")
+		quality := utils.ExtractQuality(releaseTitle)
+		if !seen[quality] {
+			seen[quality] = true
+			heads = append(heads, s)
+		} else {
+			rest = append(rest, s)
+		}
+	}
+	copy(streams, append(heads, rest...))
+}
+
 func (ta *TorBoxStremioAddon) buildSearchQuery(req stream.StreamRequest) types.ScrapeRequest {
 	scrapeReq := types.ScrapeRequest{
 		Title:       ta.getTitleFromIMDb(req.ID), // You'd need to implement this
@@ -176,6 +1019,19 @@ func (ta *TorBoxStremioAddon) buildSearchQuery(req stream.StreamRequest) types.S
 		scrapeReq.Season = req.Season
 		episode := req.Episode
 		scrapeReq.Episode = &episode
+		scrapeReq.AbsoluteEpisode = req.AbsoluteEpisode
+	}
+
+	if ta.metadataProvider != nil {
+		// The metadata is cached by the title lookup above
+		if meta, err := ta.metadataProvider.GetMetadataFromTMDB(req.ID); err == nil {
+			if ta.config.MovieQueryYear && !req.IsSeries() {
+				scrapeReq.Year = meta.Year
+			}
+			if ta.config.SearchOriginalTitle && meta.OriginalTitle != "" && !strings.EqualFold(meta.OriginalTitle, scrapeReq.Title) {
+				scrapeReq.AltTitles = append(scrapeReq.AltTitles, meta.OriginalTitle)
+			}
+		}
 	}
 
 	return scrapeReq
@@ -183,7 +1039,10 @@ func (ta *TorBoxStremioAddon) buildSearchQuery(req stream.StreamRequest) types.S
 
 func (ta *TorBoxStremioAddon) searchTorrents(ctx context.Context, query types.ScrapeRequest) ([]types.ScrapeResult, error) {
 	// Create a torrent manager with TorBox integration
-	torrentMgr := torrentManager.NewTorrentManager(ta.torboxClient)
+	torrentMgr := torrentManager.NewTorrentManager(ta.debridClient, torrentManager.DownloadConfig{
+		Timeout:  ta.config.TorrentDownloadTimeout,
+		MaxBytes: ta.config.TorrentMaxBytes,
+	})
 	// Create channels to receive results
 	type searchResult struct {
 		results []types.ScrapeResult
@@ -198,20 +1057,25 @@ func (ta *TorBoxStremioAddon) searchTorrents(ctx context.Context, query types.Sc
 	}()
 	// Collect results
 	var allResults []types.ScrapeResult
-	var errors []error
 	result := <-resultsChan
 	if result.err != nil {
 		log.Printf("⚠️  %s search failed: %v", result.source, result.err)
-		errors = append(errors, fmt.Errorf("%s search failed: %w", result.source, result.err))
 	} else {
 		log.Printf("✅ %s returned %d results", result.source, len(result.results))
-		allResults = append(allResults, result.results...)
 	}
+	// Keep partial results of a search cut short by the deadline
+	allResults = append(allResults, result.results...)
 
+	if ctx.Err() != nil {
+		return allResults, ctx.Err()
+	}
+	if errors.Is(result.err, scrapers.ErrIndexerUnavailable) {
+		return allResults, fmt.Errorf("%s search failed: %w", result.source, result.err)
+	}
 	return allResults, nil
 }
 
-func (ta *TorBoxStremioAddon) checkCacheAndBuildStreams(torrents []types.ScrapeResult, req stream.StreamRequest) ([]stream.Stream, error) {
+func (ta *TorBoxStremioAddon) checkCacheAndBuildStreams(ctx context.Context, torrents []types.ScrapeResult, req stream.StreamRequest) ([]stream.Stream, error) {
 	// Extract unique hashes
 	hashMap := make(map[string]types.ScrapeResult)
 	var hashes []string
@@ -231,19 +1095,34 @@ func (ta *TorBoxStremioAddon) checkCacheAndBuildStreams(torrents []types.ScrapeR
 		return []stream.Stream{}, nil
 	}
 
-	log.Printf("🔎 Checking %d hashes in TorBox cache", len(hashes))
+	log.Printf("🔎 Checking %d hashes in %s cache", len(hashes), ta.debridClient.Name())
 
-	// Check cache with TorBox
-	cached, err := ta.torboxClient.CheckCache(hashes)
+	cached, err := ta.debridClient.CheckCacheWithContext(ctx, hashes)
 	if err != nil {
-		return nil, fmt.Errorf("torbox cache check failed: %w", err)
+		return nil, fmt.Errorf("%s cache check failed: %w", ta.debridClient.Name(), err)
 	}
 
 	// Build streams from cached results with file filtering
 	var streams []stream.Stream
+	var pending []pendingStream
 	isSeries := req.IsSeries()
 
+	// Only the first matching season pack is looked ahead in, bounding the extra TorBox calls
+	prewarmed := false
+
+	// Why torrents and files were dropped, logged once per request
+	drops := dropTally{}
+	drops.add("torrent not cached", len(hashes)-len(cached))
+	defer func() { drops.log(req) }()
+
 	for _, item := range cached {
+		// Return what was built so far once the deadline passes
+		if err := ctx.Err(); err != nil {
+			log.Printf("⏱ Stopping file lookups: %v", err)
+			drops.add("deadline", 1)
+			return append(streams, ta.buildStreamsWithURL(pending, req, drops)...), err
+		}
+
 		hash := item.Hash
 		if hash == "" {
 			continue
@@ -258,86 +1137,444 @@ func (ta *TorBoxStremioAddon) checkCacheAndBuildStreams(torrents []types.ScrapeR
 		log.Printf("✅ Cached torrent: %s (hash: %s)", torrent.Title, hash)
 
 		// Get file list for the cached torrent
-		files, torrentID, err := ta.torboxClient.GetTorrentFiles(hash)
+		files, torrentID, err := ta.debridClient.GetTorrentFiles(hash)
+		if errors.Is(err, debrid.ErrRateLimited) || errors.Is(err, debrid.ErrUnauthorized) {
+			// No point hammering TorBox for the remaining torrents
+			log.Printf("⚠️  Stopping file lookups: %v", err)
+			break
+		}
 		if err != nil {
 			log.Printf("⚠️  Failed to get files for %s: %v, using fallback", hash, err)
 			// Fallback to InfoHash method
-			streamed := ta.buildStream(torrent, req)
-			streams = append(streams, streamed)
+			if ta.p2pAllowed(torrent) {
+				streams = append(streams, ta.buildStream(torrent, req))
+			} else {
+				drops.add("file list failed", 1)
+			}
 			continue
 		}
 
 		log.Printf("   Found %d files in torrent (ID: %s)", len(files), torrentID)
 
+		largestVideo := debrid.LargestVideoSize(files)
+		pickedMain := false
+
+		// Videos passing the size filters, for the best-guess fallback of odd episode names
+		var episodeCandidates []debrid.CachedFileInfo
+		episodeMatched := false
+
 		for _, file := range files {
 			// Filter 1: Must be a video file
 			if !debrid.IsVideoFile(file.Name) {
 				log.Printf("   ⏭️  Skipping non-video file: %s", file.Name)
+				drops.add("non-video", 1)
+				continue
+			}
+
+			// Filter 1a: Must be present in a partially cached torrent
+			if !file.Available() {
+				log.Printf("   ⏭️  Skipping file not cached yet: %s", file.Name)
+				drops.add("file not cached", 1)
+				continue
+			}
+
+			// Filter 1b: Must not be a sample clip
+			if debrid.IsSampleFile(file.Name, file.Size, largestVideo) {
+				log.Printf("   ⏭️  Skipping sample file: %s", file.Name)
+				drops.add("sample", 1)
 				continue
 			}
 
 			// Filter 2: Must meet minimum size requirements
 			if !debrid.IsFileSizeValid(file.Size, isSeries) {
 				log.Printf("   ⏭️  Skipping file too small (%s): %s", debrid.FormatBytes(file.Size), file.Name)
+				drops.add("too small", 1)
 				continue
 			}
 
-			// Filter 3: For series, must match episode pattern
-			if isSeries && !debrid.IsEpisodeFile(file.Name, req.Season, req.Episode) {
-				continue
+			// Filter 3: For series, must match episode pattern (or the anime absolute number)
+			if isSeries {
+				episodeCandidates = append(episodeCandidates, file)
+				if !debrid.IsEpisodeFile(file.Name, req.Season, req.Episode) &&
+					!(req.AbsoluteEpisode > 0 && debrid.IsAbsoluteEpisodeFile(file.Name, req.AbsoluteEpisode)) {
+					drops.add("wrong episode", 1)
+					continue
+				}
+				episodeMatched = true
+			}
+
+			// Filter 4: For movies, apply the configured file selection mode
+			if !isSeries {
+				switch ta.config.MovieFileMode {
+				case MovieFilesAll:
+				case MovieFilesThreshold:
+					if file.Size < ta.config.MovieFileMinSize {
+						log.Printf("   ⏭️  Skipping file below threshold (%s): %s", debrid.FormatBytes(file.Size), file.Name)
+						drops.add("below threshold", 1)
+						continue
+					}
+				default:
+					if pickedMain || file.Size < largestVideo {
+						log.Printf("   ⏭️  Skipping extra file: %s", file.Name)
+						drops.add("extra file", 1)
+						continue
+					}
+					pickedMain = true
+				}
 			}
 
 			log.Printf("   ✅ Valid file: %s (%s)", file.Name, debrid.FormatBytes(file.Size))
 
-			// Build stream with URL from requestdl
-			streamed := ta.buildStreamWithURL(torrent, file, torrentID, req)
-			streams = append(streams, streamed)
+			// Queue the stream, its URL is requested below
+			pending = append(pending, pendingStream{torrent: torrent, file: file, torrentID: torrentID})
+		}
+
+		// A single-episode torrent with one real video is most likely the episode, whatever its name
+		if isSeries && !episodeMatched && ta.config.EpisodeBestGuess &&
+			len(episodeCandidates) == 1 && !scrapers.IsPack(torrent.Title) {
+			file := episodeCandidates[0]
+			log.Printf("   ❓ Best guess file: %s (%s)", file.Name, debrid.FormatBytes(file.Size))
+			pending = append(pending, pendingStream{torrent: torrent, file: file, torrentID: torrentID, bestGuess: true})
+		}
+
+		if isSeries && episodeMatched && !prewarmed && ta.config.EpisodeLookahead > 0 && scrapers.IsPack(torrent.Title) {
+			ta.prewarmNextEpisodes(files, torrentID, req)
+			prewarmed = true
 		}
 	}
 
+	streams = append(streams, ta.buildStreamsWithURL(pending, req, drops)...)
+
 	log.Printf("📤 Returning %d streams after filtering", len(streams))
 	return streams, nil
 }
 
+// dropTally counts why torrents and files were dropped while building streams
+type dropTally map[string]int
+
+func (d dropTally) add(reason string, n int) {
+	if n > 0 {
+		d[reason] += n
+	}
+}
+
+// log writes one summary line of the drop reasons, most frequent first
+func (d dropTally) log(req stream.StreamRequest) {
+	if len(d) == 0 {
+		return
+	}
+
+	reasons := make([]string, 0, len(d))
+	for reason := range d {
+		reasons = append(reasons, reason)
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if d[reasons[i]] != d[reasons[j]] {
+			return d[reasons[i]] > d[reasons[j]]
+		}
+		return reasons[i] < reasons[j]
+	})
+
+	parts := make([]string, len(reasons))
+	for i, reason := range reasons {
+		parts[i] = fmt.Sprintf("%s %d", reason, d[reason])
+	}
+	log.Printf("📊 Dropped for %s: %s", req.String(), strings.Join(parts, ", "))
+}
+
+// pendingStream is a valid file whose download URL still has to be requested
+type pendingStream struct {
+	torrent   types.ScrapeResult
+	file      debrid.CachedFileInfo
+	torrentID string
+	bestGuess bool // the file didn't match the episode pattern
+}
+
+// buildStreamsWithURL requests the download URLs of pending files concurrently, keeping their order
+func (ta *TorBoxStremioAddon) buildStreamsWithURL(pending []pendingStream, req stream.StreamRequest, drops dropTally) []stream.Stream {
+	streams := make([]stream.Stream, len(pending))
+
+	// Lazy mode: point at /resolve, the link is only requested when a stream is played
+	if ta.config.LazyUnrestrict {
+		for i, p := range pending {
+			streams[i] = ta.tagBestGuess(ta.buildURLStream(p.torrent, p.file, ta.resolveURL(p.torrent.InfoHash, p.torrentID, p.file), req), p)
+		}
+		// A link failing at play time can't fall back to InfoHash, so always offer the P2P stream next to it
+		return ta.withP2PAlternates(streams, pending, req)
+	}
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, max(ta.config.UnrestrictConcurrency, 1))
+
+	for i, p := range pending {
+		wg.Add(1)
+		go func(i int, p pendingStream) {
+			defer wg.Done()
+
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			streams[i] = ta.buildStreamWithURL(p.torrent, p.file, p.torrentID, req)
+		}(i, p)
+	}
+
+	wg.Wait()
+
+	// Drop the P2P fallbacks refused for too few seeders
+	built := streams[:0]
+	var kept []pendingStream
+	for i, s := range streams {
+		if s.URL != "" || s.InfoHash != "" {
+			built = append(built, ta.tagBestGuess(s, pending[i]))
+			kept = append(kept, pending[i])
+		}
+	}
+	drops.add("link failed, P2P refused", len(pending)-len(built))
+
+	if ta.config.P2PAlternates {
+		return ta.withP2PAlternates(built, kept, req)
+	}
+	return built
+}
+
+// tagBestGuess marks the description of a stream whose file was picked without an episode match
+func (ta *TorBoxStremioAddon) tagBestGuess(s stream.Stream, p pendingStream) stream.Stream {
+	if !p.bestGuess {
+		return s
+	}
+	if ta.config.PlainTitles {
+		s.Description += " | best guess"
+	} else {
+		s.Description += " ❓ Best guess"
+	}
+	return s
+}
+
+// p2pAllowed reports whether a torrent has enough seeders to be offered as a P2P (InfoHash) stream
+func (ta *TorBoxStremioAddon) p2pAllowed(torrent types.ScrapeResult) bool {
+	if seedersOf(torrent) >= ta.config.P2PMinSeeders {
+		return true
+	}
+	log.Printf("⏭️  Not offering P2P fallback for %s: %d seeders (min %d)", torrent.Title, seedersOf(torrent), ta.config.P2PMinSeeders)
+	return false
+}
+
+// buildStreamWithURL returns a zero stream when the link fails and the P2P fallback isn't allowed
 func (ta *TorBoxStremioAddon) buildStreamWithURL(torrent types.ScrapeResult, file debrid.CachedFileInfo, torrentID string, req stream.StreamRequest) stream.Stream {
 	// Format title with quality and source info
 	title := ta.formatStreamTitleWithFile(torrent, file)
 
 	// Build file ID for download
-	fileID := fmt.Sprintf("%s,%d", torrentID, file.Index)
+	fileID := file.FileID(torrentID)
 
 	// Get download URL from TorBox
-	downloadURL, err := ta.torboxClient.UnrestrictLink(fileID)
+	downloadURL, err := ta.unrestrictWithRetry(torrent, file, fileID)
 	if err != nil {
 		log.Printf("⚠️  Failed to get download link for %s: %v, falling back to InfoHash", file.Name, err)
-		// Fallback to InfoHash method
-		return stream.Stream{
-			InfoHash:    torrent.InfoHash,
-			FileIdx:     file.Index,
-			Description: title,
-			Name:        "TorBox",
-			Sources:     torrent.Sources,
-			BehaviorHints: &stream.StreamBehaviorHints{
-				BingeGroup:  ta.getBingeGroup(req) + torrent.InfoHash,
-				VideoSize:   file.Size,
-				Filename:    file.Name,
-				NotWebReady: true,
-			},
+		if !ta.p2pAllowed(torrent) {
+			return stream.Stream{}
 		}
+		// Fallback to InfoHash method
+		return ta.buildFileP2PStream(torrent, file, title, ta.streamName(), ta.getBingeGroup(req))
 	}
 
 	// Return stream with direct URL
+	return ta.buildURLStream(torrent, file, downloadURL, req)
+}
+
+// buildFileP2PStream builds an InfoHash stream playing a file of the torrent
+func (ta *TorBoxStremioAddon) buildFileP2PStream(torrent types.ScrapeResult, file debrid.CachedFileInfo, title, name, bingeGroup string) stream.Stream {
 	return stream.Stream{
-		URL:         downloadURL,
+		InfoHash:    torrent.InfoHash,
+		FileIdx:     file.Index,
 		Description: title,
-		Name:        "TorBox",
+		Name:        name,
+		Sources:     utils.MergeTrackers(torrent.Sources, ta.trackers.Get()),
+		BehaviorHints: &stream.StreamBehaviorHints{
+			BingeGroup:  bingeGroup + torrent.InfoHash,
+			VideoSize:   file.Size,
+			Filename:    file.Name,
+			NotWebReady: true,
+		},
+		Seeders: seedersOf(torrent),
+		Quality: utils.ExtractQuality(torrent.Title),
+	}
+}
+
+// withP2PAlternates follows every direct-URL stream with a P2P stream of the same file,
+// so the file stays playable when the debrid link fails at play time
+func (ta *TorBoxStremioAddon) withP2PAlternates(streams []stream.Stream, pending []pendingStream, req stream.StreamRequest) []stream.Stream {
+	result := make([]stream.Stream, 0, 2*len(streams))
+	for i, s := range streams {
+		result = append(result, s)
+		p := pending[i]
+		if s.URL == "" || !ta.p2pAllowed(p.torrent) {
+			continue
+		}
+		alternate := ta.buildFileP2PStream(p.torrent, p.file, ta.formatStreamTitleWithFile(p.torrent, p.file),
+			ta.streamName()+" P2P", ta.getBingeGroup(req)+"p2p|")
+		result = append(result, ta.tagBestGuess(alternate, p))
+	}
+	return result
+}
+
+// unrestrictWithRetry requests the download link of a file and, when it fails because the
+// torrent was evicted since the cache check, looks the torrent up again and retries once.
+// The lookup reuses the torrent still in the account, so no duplicate is added.
+func (ta *TorBoxStremioAddon) unrestrictWithRetry(torrent types.ScrapeResult, file debrid.CachedFileInfo, fileID string) (string, error) {
+	downloadURL, err := ta.unrestrictCached(fileID)
+	if err == nil || errors.Is(err, debrid.ErrRateLimited) || errors.Is(err, debrid.ErrUnauthorized) ||
+		errors.Is(err, debrid.ErrTorrentNotReady) {
+		return downloadURL, err
+	}
+
+	log.Printf("🔁 Link for %s failed (%v), looking the torrent up again and retrying", file.Name, err)
+
+	files, torrentID, lookupErr := ta.debridClient.GetTorrentFiles(torrent.InfoHash)
+	if lookupErr != nil {
+		return "", fmt.Errorf("%w (looking the torrent up again failed: %v)", err, lookupErr)
+	}
+	for _, f := range files {
+		if f.Name == file.Name {
+			return ta.debridClient.UnrestrictLink(f.FileID(torrentID))
+		}
+	}
+	return "", fmt.Errorf("%w (%s is no longer in the torrent)", err, file.Name)
+}
+
+// prewarmedLinkTTL is how long a download link resolved ahead of time is reused
+const prewarmedLinkTTL = 30 * time.Minute
+
+func prewarmedLinkKey(fileID string) string {
+	return "link_" + fileID
+}
+
+// unrestrictCached requests the download link of a file, reusing one pre-warmed by the episode look-ahead
+func (ta *TorBoxStremioAddon) unrestrictCached(fileID string) (string, error) {
+	if link, found := types.CacheGet[string](ta.cache, prewarmedLinkKey(fileID)); found {
+		log.Printf("⚡ Using pre-warmed link for %s", fileID)
+		return link, nil
+	}
+	return ta.debridClient.UnrestrictLink(fileID)
+}
+
+// prewarmNextEpisodes resolves the links of the episodes after the requested one in a cached
+// season pack, so auto-play of the next episode (same binge group) starts instantly
+func (ta *TorBoxStremioAddon) prewarmNextEpisodes(files []debrid.CachedFileInfo, torrentID string, req stream.StreamRequest) {
+	var next []debrid.CachedFileInfo
+	for ahead := 1; ahead <= ta.config.EpisodeLookahead; ahead++ {
+		for _, file := range files {
+			if debrid.IsVideoFile(file.Name) && file.Available() &&
+				debrid.IsEpisodeFile(file.Name, req.Season, req.Episode+ahead) {
+				next = append(next, file)
+				break
+			}
+		}
+	}
+	if len(next) == 0 || ta.background.Err() != nil {
+		return
+	}
+
+	// Pre-warming is best effort, skip it when enough packs are being pre-warmed already
+	select {
+	case ta.prewarmSlots <- struct{}{}:
+	default:
+		log.Printf("⏭️  Skipping pre-warm of %d episodes, %d pre-warms already running", len(next), cap(ta.prewarmSlots))
+		return
+	}
+
+	ta.backgroundWork.Add(1)
+	go func() {
+		defer ta.backgroundWork.Done()
+		defer func() { <-ta.prewarmSlots }()
+
+		for _, file := range next {
+			if ta.background.Err() != nil {
+				return
+			}
+			fileID := file.FileID(torrentID)
+			link, err := ta.debridClient.UnrestrictLink(fileID)
+			if err != nil {
+				log.Printf("⚠️  Failed to pre-warm %s: %v", file.Name, err)
+				continue
+			}
+			ta.cache.Set(prewarmedLinkKey(fileID), link, prewarmedLinkTTL)
+			log.Printf("🔥 Pre-warmed next episode: %s", file.Name)
+		}
+	}()
+}
+
+// stopBackgroundWork stops the tracker refresh, cancels the work left running by requests and waits for it
+func (ta *TorBoxStremioAddon) stopBackgroundWork() {
+	ta.stopBackground()
+	ta.backgroundWork.Wait()
+}
+
+// buildURLStream builds a stream playing link, either a TorBox link or a lazy /resolve link
+func (ta *TorBoxStremioAddon) buildURLStream(torrent types.ScrapeResult, file debrid.CachedFileInfo, link string, req stream.StreamRequest) stream.Stream {
+	return stream.Stream{
+		URL:         link,
+		Description: ta.formatStreamTitleWithFile(torrent, file),
+		Name:        ta.streamName(),
 		BehaviorHints: &stream.StreamBehaviorHints{
 			BingeGroup:  ta.getBingeGroup(req) + torrent.InfoHash,
 			VideoSize:   file.Size,
 			Filename:    file.Name,
-			NotWebReady: false,
+			NotWebReady: debrid.IsContainerFile(file.Name),
 		},
+		Seeders: seedersOf(torrent),
+		Quality: utils.ExtractQuality(torrent.Title),
+	}
+}
+
+// handleReady reports whether the addon can serve streams: 200 when Jackett was reachable
+// on its last request, 503 when Jackett is down
+func (ta *TorBoxStremioAddon) handleReady(w http.ResponseWriter, r *http.Request) {
+	status, jackett := http.StatusOK, "up"
+	if !ta.jackettScraper.Available() {
+		status, jackett = http.StatusServiceUnavailable, "down"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"jackett": jackett})
+}
+
+// handlePrefetch queues a prefetch of POST /prefetch?type=series&id=tt123, authorized by the admin token
+func (ta *TorBoxStremioAddon) handlePrefetch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
+	if !ta.refreshAllowed(r.Header.Get("X-Admin-Token")) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	mediaType, imdbID := r.URL.Query().Get("type"), r.URL.Query().Get("id")
+	if (mediaType != "movie" && mediaType != "series") || !strings.HasPrefix(imdbID, "tt") {
+		http.Error(w, "type must be movie or series and id an IMDb ID", http.StatusBadRequest)
+		return
+	}
+
+	task, position, err := ta.backgroundWorker.PrefetchNow(mediaType, imdbID)
+	if err != nil {
+		log.Printf("❌ Failed to queue prefetch of %s: %v", imdbID, err)
+		if errors.Is(err, caching.ErrQueueFull) {
+			http.Error(w, "Prefetch queue full", http.StatusServiceUnavailable)
+		} else {
+			http.Error(w, "Failed to queue prefetch", http.StatusBadRequest)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"taskId":   task.Type + ":" + task.IMDbID,
+		"title":    task.Title,
+		"position": position,
+	})
 }
 
 func (ta *TorBoxStremioAddon) buildStream(torrent types.ScrapeResult, req stream.StreamRequest) stream.Stream {
@@ -354,20 +1591,102 @@ func (ta *TorBoxStremioAddon) buildStream(torrent types.ScrapeResult, req stream
 		InfoHash:    torrent.InfoHash,
 		FileIdx:     fileIdx,
 		Description: title,
-		Name:        "TorBox",
-		Sources:     torrent.Sources,
+		Name:        ta.streamName(),
+		Sources:     utils.MergeTrackers(torrent.Sources, ta.trackers.Get()),
 		BehaviorHints: &stream.StreamBehaviorHints{
 			BingeGroup:  ta.getBingeGroup(req) + torrent.InfoHash,
 			VideoSize:   torrent.Size,
 			Filename:    torrent.Title,
 			NotWebReady: true,
 		},
+		Seeders: seedersOf(torrent),
+		Quality: utils.ExtractQuality(torrent.Title),
 	}
 
 	return streamed
 }
 
+// reliabilityTier rates how likely a torrent is to play well from its seeders and tracker
+func (ta *TorBoxStremioAddon) reliabilityTier(torrent types.ScrapeResult) string {
+	seeders := 0
+	if torrent.Seeders != nil {
+		seeders = *torrent.Seeders
+	}
+
+	level := 0
+	if seeders >= ta.config.ReliabilityHighSeeders {
+		level = 2
+	} else if seeders >= ta.config.ReliabilityLowSeeders {
+		level = 1
+	}
+
+	// A preferred tracker bumps the tier by one
+	tracker := strings.ToLower(strings.Split(torrent.Tracker, " (")[0])
+	for _, preferred := range ta.config.PreferredTrackers {
+		if tracker != "" && strings.EqualFold(tracker, preferred) {
+			level++
+			break
+		}
+	}
+
+	switch {
+	case level >= 2:
+		if ta.config.PlainTitles {
+			return "[Good]"
+		}
+		return "🟢"
+	case level == 1:
+		if ta.config.PlainTitles {
+			return "[Fair]"
+		}
+		return "🟡"
+	default:
+		if ta.config.PlainTitles {
+			return "[Poor]"
+		}
+		return "🔴"
+	}
+}
+
+// reliabilityPrefix returns the tier followed by a space, or "" when disabled
+func (ta *TorBoxStremioAddon) reliabilityPrefix(torrent types.ScrapeResult) string {
+	if !ta.config.ShowReliability {
+		return ""
+	}
+	return ta.reliabilityTier(torrent) + " "
+}
+
+// formatPlainTitle builds an emoji-free title: "TorBox | 1080p | H265 | 2.1 GB | 45 seeders"
+func (ta *TorBoxStremioAddon) formatPlainTitle(torrent types.ScrapeResult, size int64) string {
+	parts := []string{ta.streamName(), utils.ExtractQuality(torrent.Title)}
+
+	if codec := utils.ExtractCodec(torrent.Title); codec != "" {
+		parts = append(parts, codec)
+	}
+	if size > 0 {
+		parts = append(parts, debrid.FormatBytes(size))
+	}
+	if torrent.Seeders != nil {
+		parts = append(parts, fmt.Sprintf("%d seeders", *torrent.Seeders))
+	}
+	if source := utils.ExtractSource(torrent.Title); source != "" {
+		parts = append(parts, source)
+	}
+	if audio := utils.ExtractAudio(torrent.Title); audio != "" {
+		parts = append(parts, audio)
+	}
+	if torrent.Tracker != "" && torrent.Tracker != "all" {
+		parts = append(parts, strings.Split(torrent.Tracker, " (")[0])
+	}
+
+	return fmt.Sprintf("%s%s\n%s", ta.reliabilityPrefix(torrent), torrent.Title, strings.Join(parts, " | "))
+}
+
 func (ta *TorBoxStremioAddon) formatStreamTitle(torrent types.ScrapeResult, req stream.StreamRequest) string {
+	if ta.config.PlainTitles {
+		return ta.formatPlainTitle(torrent, torrent.Size)
+	}
+
 	// Extract quality from title
 	quality := utils.ExtractQuality(torrent.Title)
 
@@ -383,6 +1702,11 @@ func (ta *TorBoxStremioAddon) formatStreamTitle(torrent types.ScrapeResult, req
 		sourceInfo = fmt.Sprintf(" 🌟 %s", source)
 	}
 
+	// Build audio info
+	if audio := utils.ExtractAudio(torrent.Title); audio != "" {
+		sourceInfo += fmt.Sprintf(" 🔊 %s", audio)
+	}
+
 	// Build seeders info
 	seedersInfo := ""
 	if torrent.Seeders != nil {
@@ -402,16 +1726,21 @@ func (ta *TorBoxStremioAddon) formatStreamTitle(torrent types.ScrapeResult, req
 	}
 
 	// Format final title
+	reliability := ta.reliabilityPrefix(torrent)
 	if req.IsSeries() {
-		return fmt.Sprintf("%s\n⚡ TorBox %s %s%s%s%s%s",
-			torrent.Title, quality, codec, seedersInfo, sizeInfo, sourceInfo, trackerInfo)
+		return fmt.Sprintf("%s%s\n⚡ %s %s %s%s%s%s%s",
+			reliability, torrent.Title, ta.debridClient.Name(), quality, codec, seedersInfo, sizeInfo, sourceInfo, trackerInfo)
 	}
 
-	return fmt.Sprintf("%s\n⚡ TorBox %s %s%s%s%s%s",
-		torrent.Title, quality, codec, seedersInfo, sizeInfo, sourceInfo, trackerInfo)
+	return fmt.Sprintf("%s%s\n⚡ %s %s %s%s%s%s%s",
+		reliability, torrent.Title, ta.debridClient.Name(), quality, codec, seedersInfo, sizeInfo, sourceInfo, trackerInfo)
 }
 
 func (ta *TorBoxStremioAddon) formatStreamTitleWithFile(torrent types.ScrapeResult, file debrid.CachedFileInfo) string {
+	if ta.config.PlainTitles {
+		return ta.formatPlainTitle(torrent, file.Size)
+	}
+
 	// Extract quality from filename
 	quality := utils.ExtractQuality(torrent.Title)
 
@@ -427,6 +1756,11 @@ func (ta *TorBoxStremioAddon) formatStreamTitleWithFile(torrent types.ScrapeResu
 		sourceInfo = fmt.Sprintf(" 🌟 %s", source)
 	}
 
+	// Build audio info
+	if audio := utils.ExtractAudio(torrent.Title); audio != "" {
+		sourceInfo += fmt.Sprintf(" 🔊 %s", audio)
+	}
+
 	// Build seeders info
 	seedersInfo := ""
 	if torrent.Seeders != nil {
@@ -443,8 +1777,8 @@ func (ta *TorBoxStremioAddon) formatStreamTitleWithFile(torrent types.ScrapeResu
 	}
 
 	// Format final title
-	return fmt.Sprintf("%s\n⚡ TorBox %s %s%s%s%s%s",
-		torrent.Title, quality, codec, seedersInfo, sizeInfo, sourceInfo, trackerInfo)
+	return fmt.Sprintf("%s%s\n⚡ %s %s %s%s%s%s%s",
+		ta.reliabilityPrefix(torrent), torrent.Title, ta.debridClient.Name(), quality, codec, seedersInfo, sizeInfo, sourceInfo, trackerInfo)
 }
 
 func (ta *TorBoxStremioAddon) getTitleFromIMDb(imdbID string) string {
@@ -464,25 +1798,55 @@ func (ta *TorBoxStremioAddon) getTitleFromIMDb(imdbID string) string {
 }
 
 func (ta *TorBoxStremioAddon) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	ta.addon.ServeHTTP(w, r)
+	if ta.config.LogFormat == "json" {
+		serveWithAccessLog(w, r, http.HandlerFunc(ta.serveHTTP))
+		return
+	}
+	ta.serveHTTP(w, r)
 }
 
-func (ta *TorBoxStremioAddon) getBingeGroup(req stream.StreamRequest) string {
-	if req.IsSeries() {
-		return fmt.Sprintf("torbox|%s|", req.ID)
+func (ta *TorBoxStremioAddon) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/version" && r.Method == http.MethodGet {
+		handleVersion(w, r)
+		return
 	}
-	return fmt.Sprintf("torbox|%s|", req.ID)
+	if r.URL.Path == "/ready" && r.Method == http.MethodGet {
+		ta.handleReady(w, r)
+		return
+	}
+	if r.URL.Path == "/stats" && r.Method == http.MethodGet {
+		ta.handleStats(w, r)
+		return
+	}
+	if r.URL.Path == "/" && r.Method == http.MethodGet && ta.handleRoot(w, r) {
+		return
+	}
+	if r.URL.Path == "/torbox/webhook" && ta.config.WebhookSecret != "" {
+		ta.handleTorBoxWebhook(w, r)
+		return
+	}
+	if r.URL.Path == "/prefetch" {
+		ta.handlePrefetch(w, r)
+		return
+	}
+	if strings.HasPrefix(r.URL.Path, "/resolve/") && r.Method == http.MethodGet {
+		ta.handleResolve(w, r)
+		return
+	}
+	ta.addon.ServeHTTP(w, r)
 }
 
-// getEnvDuration reads a duration from environment variable (in minutes) or returns a default
-func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
-	if value := os.Getenv(key); value != "" {
-		if minutes, err := strconv.Atoi(value); err == nil {
-			return time.Duration(minutes) * time.Minute
-		}
-		log.Printf("⚠️  Invalid value for %s: %s, using default", key, value)
+// streamName is the provider label shown as the stream name, with the optional instance tag
+func (ta *TorBoxStremioAddon) streamName() string {
+	if ta.config.InstanceTag == "" {
+		return ta.debridClient.Name()
 	}
-	return defaultValue
+	return ta.debridClient.Name() + " " + ta.config.InstanceTag
+}
+
+// getBingeGroup is the binge group prefix every stream of a title shares, followed by the info hash
+func (ta *TorBoxStremioAddon) getBingeGroup(req stream.StreamRequest) string {
+	return fmt.Sprintf("torbox|%s|", req.ID)
 }
 
 func gracefulShutdown(server *http.Server, addon *TorBoxStremioAddon) {
@@ -503,10 +1867,14 @@ func gracefulShutdown(server *http.Server, addon *TorBoxStremioAddon) {
 	// Stop background workers and wait for completion
 	log.Println("🛑 Stopping background workers...")
 	addon.backgroundWorker.StopAndWait()
+	addon.stopBackgroundWork()
 
 	// Flush caches to disk
 	log.Println("💾 Flushing caches to disk...")
-	addon.cache.Flush()
+	if err := addon.cache.Flush(); err != nil {
+		log.Printf("⚠️ Failed to flush cache: %v", err)
+	}
+	addon.cache.Close()
 
 	log.Println("✅ Graceful shutdown complete")
 }
@@ -520,54 +1888,55 @@ func main() {
 	}
 	fmt.Println("===========================================")
 	fmt.Println("  Stremfy Stremio Addon")
+	fmt.Printf("  Version %s (%s)\n", version, buildCommit())
 	fmt.Println("===========================================")
 	fmt.Println()
 	// Get configuration from environment variables
-	torboxAPIKey := os.Getenv("TORBOX_API_KEY")
-	if torboxAPIKey == "" {
+	config := loadConfig()
+	if config.DebridService == debridRealDebrid && config.RealDebridAPIKey == "" {
+		log.Fatal("❌ REALDEBRID_API_KEY environment variable is required")
+	}
+	if config.DebridService == debridAllDebrid && config.AllDebridAPIKey == "" {
+		log.Fatal("❌ ALLDEBRID_API_KEY environment variable is required")
+	}
+	if config.DebridService == debridTorBox && config.TorBoxAPIKey == "" {
 		log.Fatal("❌ TORBOX_API_KEY environment variable is required")
 	}
 
-	jackettURL := os.Getenv("JACKETT_URL")
-	if jackettURL == "" {
-		jackettURL = "http://localhost:9117"
+	if config.JackettAPIKey == "" {
+		log.Fatal("❌ JACKETT_API_KEY environment variable is required")
 	}
 
-	jackettAPIKey := os.Getenv("JACKETT_API_KEY")
-	if jackettAPIKey == "" {
-		log.Fatal("❌ JACKETT_API_KEY environment variable is required")
+	if config.JackettURL == "" {
+		log.Fatal("❌ JACKETT_URL environment variable is required with INDEXER_TYPE=torznab")
 	}
 
-	tmdbAPIKey := os.Getenv("TMDB_API_KEY")
-	if tmdbAPIKey == "" {
+	if config.TMDBAPIKey == "" {
 		log.Fatal("❌ TMDB_API_KEY environment variable is required")
 	}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
+	port := config.Port
 	fmt.Printf("✅ Port: %s\n", port)
 
-	// Get cache configuration from environment variables
-	searchTTL := getEnvDuration("CACHE_SEARCH_TTL", 30*time.Minute)
-	metadataTTL := getEnvDuration("CACHE_METADATA_TTL", 24*time.Hour)
-	torboxTTL := getEnvDuration("CACHE_TORBOX_CHECK_TTL", 10*time.Minute)
-
 	fmt.Println()
 
 	// Create addon
 	fmt.Println("🔧 Initializing addon...")
-	addon := NewTorBoxStremioAddon(torboxAPIKey, jackettURL, jackettAPIKey, tmdbAPIKey, searchTTL, metadataTTL, torboxTTL)
+	cache := newCache(config)
+	addon := NewTorBoxStremioAddon(config, cache, newDebridProvider(config, cache))
 	fmt.Println("✅ Addon initialized")
 	fmt.Println()
 
+	if config.EnablePprof {
+		startPprof(config.PprofAddr)
+	}
+
 	// Setup HTTP server
 	server := &http.Server{
 		Addr:         ":" + port,
 		Handler:      addon,
 		ReadTimeout:  30 * time.Second,
-		WriteTimeout: 30 * time.Second,
+		WriteTimeout: max(30*time.Second, config.StreamTimeout+5*time.Second),
 		IdleTimeout:  120 * time.Second,
 	}
 
@@ -586,9 +1955,11 @@ func main() {
 	fmt.Println()
 	// Start server
 	log.Printf("Listening on port %s...", port)
-	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Fatalf("❌ Server failed: %v", err)
-	}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("❌ Server failed: %v", err)
+		}
+	}()
 
 	<-sigChan
 	gracefulShutdown(server, addon)
@@ -0,0 +1,512 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"stremfy/caching"
+	"stremfy/debrid"
+	"stremfy/stream"
+	"stremfy/types"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// shawshankHash is the info hash of the only release the fake Jackett returns
+const shawshankHash = "0123456789abcdef0123456789abcdef01234567"
+
+// fakeUpstream serves canned JSON by path, failing the test on any other request
+func fakeUpstream(t *testing.T, name string, routes map[string]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := routes[r.URL.Path]
+		if !ok {
+			t.Errorf("%s: unexpected request %s %s", name, r.Method, r.URL)
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newTestAddon builds the addon from settings, on top of an in-memory cache and no background prefetch
+func newTestAddon(t *testing.T, settings map[string]string) *TorBoxStremioAddon {
+	t.Helper()
+	for key, value := range map[string]string{
+		"TORBOX_API_KEY":    "torbox-key",
+		"CACHE_MEMORY_ONLY": "true",
+		"PREFETCH_TRENDING": "false",
+		"PREFETCH_ON_WATCH": "false",
+		"CONFIG_FILE":       "",
+		"DEBRID_SERVICE":    "",
+		"INDEXER_TYPE":      "",
+		"JACKETT_INSTANCES": "",
+		"LAZY_UNRESTRICT":   "",
+	} {
+		t.Setenv(key, value)
+	}
+	for key, value := range settings {
+		t.Setenv(key, value)
+	}
+
+	config := loadConfig()
+	cache := newCache(config)
+	return NewTorBoxStremioAddon(config, cache, newDebridProvider(config, cache))
+}
+
+func TestStreamEndToEnd(t *testing.T) {
+	jackett := fakeUpstream(t, "jackett", map[string]string{
+		"/api/v2.0/indexers/all/results": `{"Results":[{
+			"Title":"The Shawshank Redemption 1994 1080p BluRay x264-GROUP",
+			"Guid":"https://tracker.example/t/1","Details":"https://tracker.example/t/1",
+			"InfoHash":"` + shawshankHash + `",
+			"MagnetUri":"magnet:?xt=urn:btih:` + shawshankHash + `&tr=udp://tracker.example:1337",
+			"Seeders":120,"Size":2500000000,"Tracker":"example","Category":[2040]}]}`,
+	})
+	torbox := fakeUpstream(t, "torbox", map[string]string{
+		"/torrents/checkcached":   `{"success":true,"data":[{"hash":"` + shawshankHash + `"}]}`,
+		"/torrents/createtorrent": `{"success":true,"data":{"torrent_id":42}}`,
+		"/torrents/mylist": `{"success":true,"data":{"id":42,"hash":"` + shawshankHash + `","download_finished":true,"files":[
+			{"id":0,"name":"Shawshank/The.Shawshank.Redemption.1994.1080p.mkv","size":2400000000},
+			{"id":1,"name":"Shawshank/sample.mkv","size":20000000},
+			{"id":2,"name":"Shawshank/info.nfo","size":1000}]}}`,
+		"/torrents/requestdl": `{"success":true,"data":"https://cdn.torbox.example/42/0"}`,
+	})
+	tmdb := fakeUpstream(t, "tmdb", map[string]string{
+		"/find/tt0111161": `{"movie_results":[{"id":278,"title":"The Shawshank Redemption",
+			"original_title":"The Shawshank Redemption","release_date":"1994-09-23"}],"tv_results":[]}`,
+		"/movie/278": `{"id":278,"title":"The Shawshank Redemption","runtime":142}`,
+	})
+
+	addon := newTestAddon(t, map[string]string{
+		"TORBOX_API_URL":    torbox.URL,
+		"JACKETT_URL":       jackett.URL,
+		"JACKETT_API_KEY":   "jackett-key",
+		"TMDB_API_KEY":      "tmdb-key",
+		"TMDB_API_URL":      tmdb.URL,
+		"SEARCH_MOVIE_YEAR": "false",
+	})
+
+	rec := httptest.NewRecorder()
+	addon.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stream/movie/tt0111161.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+
+	var response stream.StreamResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid response %s: %v", rec.Body, err)
+	}
+	if len(response.Streams) != 1 {
+		t.Fatalf("got %d streams, want 1: %s", len(response.Streams), rec.Body)
+	}
+
+	got := response.Streams[0]
+	if got.URL != "https://cdn.torbox.example/42/0" {
+		t.Errorf("URL = %q, want the TorBox download link", got.URL)
+	}
+	if !strings.Contains(got.Description, "The Shawshank Redemption") {
+		t.Errorf("description %q doesn't name the release", got.Description)
+	}
+	if got.BehaviorHints == nil || got.BehaviorHints.Filename != "Shawshank/The.Shawshank.Redemption.1994.1080p.mkv" {
+		t.Errorf("behavior hints = %+v, want the main video file", got.BehaviorHints)
+	}
+}
+
+func TestCloudCatalogItemsHaveStreams(t *testing.T) {
+	torbox := fakeUpstream(t, "torbox", map[string]string{
+		"/torrents/mylist": `{"success":true,"data":[{"id":7,"name":"Show.S01.1080p","hash":"` + strings.ToUpper(shawshankHash) + `",
+			"download_finished":true,"download_state":"cached","files":[
+			{"id":12,"name":"Show.S01.1080p/Show.S01E01.mkv","size":900000000},
+			{"id":15,"name":"Show.S01.1080p/Show.S01.nfo","size":1000},
+			{"id":19,"name":"Show.S01.1080p/Show.S01E02.mkv","size":800000000}]}]}`,
+		"/torrents/requestdl": `{"success":true,"data":"https://cdn.torbox.example/7"}`,
+	})
+
+	addon := newTestAddon(t, map[string]string{
+		"TORBOX_API_URL": torbox.URL,
+		"JACKETT_URL":    "http://127.0.0.1:1",
+		"CLOUD_CATALOG":  "true",
+	})
+	config := addon.config
+
+	manifest := addon.addon.Manifest()
+	streamResource := manifest.Resources[0]
+	if !slices.Contains(streamResource.Types, config.DownloadsType) || !slices.Contains(streamResource.IDPrefixes, cloudIDPrefix) {
+		t.Errorf("stream resource = %+v, want the library type and ID prefix", streamResource)
+	}
+
+	rec := httptest.NewRecorder()
+	addon.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stream/"+config.DownloadsType+"/"+cloudIDPrefix+shawshankHash+".json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+
+	var response stream.StreamResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid response %s: %v", rec.Body, err)
+	}
+
+	var files []string
+	for _, s := range response.Streams {
+		if s.URL != "https://cdn.torbox.example/7" {
+			t.Errorf("URL = %q, want the TorBox download link", s.URL)
+		}
+		files = append(files, s.BehaviorHints.Filename)
+	}
+	want := []string{"Show.S01.1080p/Show.S01E01.mkv", "Show.S01.1080p/Show.S01E02.mkv"}
+	if !slices.Equal(files, want) {
+		t.Errorf("streamed files = %v, want %v", files, want)
+	}
+}
+
+func TestCloudCatalogArtwork(t *testing.T) {
+	torbox := fakeUpstream(t, "torbox", map[string]string{
+		"/torrents/mylist": `{"success":true,"data":[
+			{"id":1,"name":"The.Matrix.1999.1080p.BluRay.x264-GROUP","hash":"AAAA","download_finished":true,"download_state":"cached"},
+			{"id":2,"name":"Severance.S01.1080p.WEB-DL","hash":"BBBB","download_finished":true,"download_state":"cached"},
+			{"id":3,"name":"Home.Video.2019.mkv","hash":"CCCC","download_finished":true,"download_state":"cached"}]}`,
+	})
+	var searches atomic.Int32
+	tmdb := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		searches.Add(1)
+		switch r.URL.Path {
+		case "/search/movie":
+			if r.URL.Query().Get("query") != "The Matrix" {
+				fmt.Fprint(w, `{"results":[]}`)
+				return
+			}
+			fmt.Fprint(w, `{"results":[
+				{"id":624860,"title":"The Matrix Resurrections","release_date":"2021-12-16","poster_path":"/resurrections.jpg"},
+				{"id":603,"title":"The Matrix","release_date":"1999-03-31","poster_path":"/matrix.jpg","backdrop_path":"/matrix-bg.jpg"}]}`)
+		case "/search/tv":
+			fmt.Fprint(w, `{"results":[{"id":95396,"name":"Severance","first_air_date":"2022-02-17","poster_path":"/severance.jpg"}]}`)
+		default:
+			t.Errorf("tmdb: unexpected request %s", r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(tmdb.Close)
+
+	addon := newTestAddon(t, map[string]string{
+		"TORBOX_API_URL": torbox.URL,
+		"TMDB_API_KEY":   "tmdb-key",
+		"TMDB_API_URL":   tmdb.URL,
+		"JACKETT_URL":    "http://127.0.0.1:1",
+		"CLOUD_CATALOG":  "true",
+	})
+
+	want := map[string][2]string{
+		cloudIDPrefix + "aaaa": {"https://image.tmdb.org/t/p/w500/matrix.jpg", "https://image.tmdb.org/t/p/original/matrix-bg.jpg"},
+		cloudIDPrefix + "bbbb": {"https://image.tmdb.org/t/p/w500/severance.jpg", ""},
+		cloudIDPrefix + "cccc": {"", ""},
+	}
+	for range 2 {
+		rec := httptest.NewRecorder()
+		addon.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/catalog/"+addon.config.DownloadsType+"/"+cloudCatalogID+".json", nil))
+
+		var response stream.CatalogResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("invalid response %s: %v", rec.Body, err)
+		}
+		if len(response.Metas) != len(want) {
+			t.Fatalf("got %d metas, want %d", len(response.Metas), len(want))
+		}
+		for _, meta := range response.Metas {
+			if got := [2]string{meta.Poster, meta.Background}; got != want[meta.ID] {
+				t.Errorf("%s artwork = %q, want %q", meta.ID, got, want[meta.ID])
+			}
+		}
+	}
+
+	// The second listing is served from the per-hash cache, misses included
+	if n := searches.Load(); n != 3 {
+		t.Errorf("searched TMDB %d times, want once per torrent", n)
+	}
+}
+
+func TestManifestNamesTheDebridService(t *testing.T) {
+	addon := newTestAddon(t, map[string]string{
+		"DEBRID_SERVICE":     debridRealDebrid,
+		"REALDEBRID_API_KEY": "rd-key",
+		"JACKETT_URL":        "http://127.0.0.1:1",
+	})
+	if got := addon.addon.Manifest().Description; !strings.HasSuffix(got, "stream with Real-Debrid") {
+		t.Errorf("manifest description = %q, want it to name Real-Debrid", got)
+	}
+}
+
+func TestMovieQueryYearIsOptIn(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("SEARCH_MOVIE_YEAR", "")
+	if loadConfig().MovieQueryYear {
+		t.Error("MovieQueryYear is on by default, want SEARCH_MOVIE_YEAR to opt in")
+	}
+
+	t.Setenv("SEARCH_MOVIE_YEAR", "true")
+	if !loadConfig().MovieQueryYear {
+		t.Error("MovieQueryYear = false with SEARCH_MOVIE_YEAR=true")
+	}
+}
+
+func TestP2PMinSeeders(t *testing.T) {
+	var linkFails atomic.Bool
+	torbox := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if linkFails.Load() {
+			http.Error(w, `{"success":false,"detail":"torrent not found"}`, http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, `{"success":true,"data":"https://cdn.torbox.example/42/3"}`)
+	}))
+	t.Cleanup(torbox.Close)
+
+	addon := newTestAddon(t, map[string]string{
+		"TORBOX_API_URL":  torbox.URL,
+		"JACKETT_URL":     "http://127.0.0.1:1",
+		"P2P_MIN_SEEDERS": "10",
+	})
+	torrent := func(n int) types.ScrapeResult {
+		return types.ScrapeResult{Title: "Inception 2010 1080p", InfoHash: shawshankHash, Seeders: &n}
+	}
+	file := debrid.CachedFileInfo{Name: "Inception.2010.1080p.mkv", Size: 2 << 30, Index: 0, ID: 3}
+	req := stream.StreamRequest{Type: "movie", ID: "tt1375666"}
+
+	// Debrid links are served whatever the seeders, the P2P threshold only gates the fallback
+	if got := addon.buildStreamWithURL(torrent(2), file, "42", req); got.URL == "" {
+		t.Errorf("stream with 2 seeders and a link = %+v, want the link", got)
+	}
+
+	linkFails.Store(true)
+	tests := []struct {
+		minSeeders int
+		seeders    int
+		wantP2P    bool
+	}{
+		{0, 0, true},
+		{10, 9, false},
+		{10, 10, true},
+		{10, 50, true},
+	}
+	for _, tt := range tests {
+		addon.config.P2PMinSeeders = tt.minSeeders
+		if got := addon.p2pAllowed(torrent(tt.seeders)); got != tt.wantP2P {
+			t.Errorf("p2pAllowed(%d seeders, min %d) = %v, want %v", tt.seeders, tt.minSeeders, got, tt.wantP2P)
+		}
+
+		got := addon.buildStreamWithURL(torrent(tt.seeders), file, "42", req)
+		if gotP2P := got.InfoHash != ""; gotP2P != tt.wantP2P {
+			t.Errorf("fallback with %d seeders, min %d: P2P = %v, want %v", tt.seeders, tt.minSeeders, gotP2P, tt.wantP2P)
+		}
+		if got.URL != "" {
+			t.Errorf("fallback URL = %q, want none when the link fails", got.URL)
+		}
+	}
+}
+
+// fakeProvider is a debrid.Provider caching every hash with files, whose links fail with
+// linkErr until the torrent is looked up again
+type fakeProvider struct {
+	files   []debrid.CachedFileInfo
+	linkErr error
+	lookups int
+	adds    int
+}
+
+func (p *fakeProvider) Name() string { return "Fake" }
+
+func (p *fakeProvider) CheckCache(hashes []string) ([]debrid.CacheCheck, error) {
+	var checks []debrid.CacheCheck
+	for _, hash := range hashes {
+		checks = append(checks, debrid.CacheCheck{Hash: hash})
+	}
+	return checks, nil
+}
+
+func (p *fakeProvider) CheckCacheWithContext(_ context.Context, hashes []string) ([]debrid.CacheCheck, error) {
+	return p.CheckCache(hashes)
+}
+
+func (p *fakeProvider) CheckCacheSingle(_ context.Context, hash string) ([]debrid.CacheCheck, error) {
+	return p.CheckCache([]string{hash})
+}
+
+func (p *fakeProvider) GetTorrentFiles(string) ([]debrid.CachedFileInfo, string, error) {
+	p.lookups++
+	p.linkErr = nil
+	return p.files, "NEW", nil
+}
+
+func (p *fakeProvider) UnrestrictLink(fileID string) (string, error) {
+	if p.linkErr != nil {
+		return "", p.linkErr
+	}
+	return "https://cdn.example/" + fileID, nil
+}
+
+func (p *fakeProvider) AddMagnet(string) (string, error) {
+	p.adds++
+	return "DUPLICATE", nil
+}
+
+func TestUnrestrictWithRetryReusesTorrent(t *testing.T) {
+	torrent := types.ScrapeResult{Title: "Movie 2020 1080p", InfoHash: shawshankHash}
+	file := debrid.CachedFileInfo{Name: "movie.mkv", ID: 2}
+
+	tests := []struct {
+		name        string
+		err         error
+		wantLink    string
+		wantLookups int
+	}{
+		{"evicted", errors.New("torrent not found"), "https://cdn.example/NEW,2", 1},
+		{"not ready", fmt.Errorf("%w: still downloading", debrid.ErrTorrentNotReady), "", 0},
+		{"rate limited", debrid.ErrRateLimited, "", 0},
+	}
+	for _, tt := range tests {
+		provider := &fakeProvider{
+			files:   []debrid.CachedFileInfo{{Name: "sample.mkv", ID: 1}, {Name: "movie.mkv", ID: 2}},
+			linkErr: tt.err,
+		}
+		ta := &TorBoxStremioAddon{debridClient: provider, cache: caching.NewCache(caching.Config{MemoryOnly: true})}
+		t.Cleanup(ta.cache.Close)
+
+		link, err := ta.unrestrictWithRetry(torrent, file, file.FileID("OLD"))
+		if link != tt.wantLink || (tt.wantLink == "") != (err != nil) {
+			t.Errorf("%s: link = %q, %v, want %q", tt.name, link, err, tt.wantLink)
+		}
+		if provider.lookups != tt.wantLookups {
+			t.Errorf("%s: looked the torrent up %d times, want %d", tt.name, provider.lookups, tt.wantLookups)
+		}
+		if provider.adds != 0 {
+			t.Errorf("%s: added the magnet %d times, want the existing torrent reused", tt.name, provider.adds)
+		}
+	}
+}
+
+func TestPartiallyCachedMovieKeepsAvailableFiles(t *testing.T) {
+	notCached := false
+	addon := newTestAddon(t, map[string]string{"JACKETT_URL": "http://127.0.0.1:1"})
+	addon.debridClient = &fakeProvider{files: []debrid.CachedFileInfo{
+		// The largest file is still downloading, so the 1080p one is the main video
+		{Name: "Movie.2020/Movie.2020.2160p.mkv", Size: 20 << 30, Index: 0, ID: 1, Cached: &notCached},
+		{Name: "Movie.2020/Movie.2020.1080p.mkv", Size: 6 << 30, Index: 1, ID: 2},
+		{Name: "Movie.2020/Movie.2020.720p.mkv", Size: 600 << 20, Index: 2, ID: 3},
+	}}
+
+	seeders := 10
+	torrents := []types.ScrapeResult{{Title: "Movie 2020 Multi", InfoHash: shawshankHash, Seeders: &seeders}}
+	streams, err := addon.checkCacheAndBuildStreams(context.Background(), torrents, stream.StreamRequest{Type: "movie", ID: "tt0000001"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var files []string
+	for _, s := range streams {
+		if s.URL != "" {
+			files = append(files, s.BehaviorHints.Filename)
+		}
+	}
+	// The 720p file is above the sample ratio of the 1080p one, but not the main video
+	if want := []string{"Movie.2020/Movie.2020.1080p.mkv"}; !slices.Equal(files, want) {
+		t.Errorf("streamed files = %v, want %v", files, want)
+	}
+}
+
+func TestSortModeFallsBackOnUnknownValues(t *testing.T) {
+	ta := &TorBoxStremioAddon{config: Config{SortMode: SortQuality}}
+
+	tests := []struct {
+		sort string
+		want string
+	}{
+		{"", SortQuality},
+		{SortSeeders, SortSeeders},
+		{SortSize, SortSize},
+		{"popularity", SortQuality},
+	}
+	for _, tt := range tests {
+		if got := ta.sortMode(stream.StreamRequest{ID: "tt0111161", Sort: tt.sort}); got != tt.want {
+			t.Errorf("sortMode(%q) = %q, want %q", tt.sort, got, tt.want)
+		}
+	}
+}
+
+func TestSortStreamsBySeedersAndQuality(t *testing.T) {
+	build := func(name string, seeders int, quality string, size int64) stream.Stream {
+		return stream.Stream{
+			Name:          name,
+			Description:   "no seeders or quality in here",
+			BehaviorHints: &stream.StreamBehaviorHints{VideoSize: size},
+			Seeders:       seeders,
+			Quality:       quality,
+		}
+	}
+	names := func(streams []stream.Stream) string {
+		var list []string
+		for _, s := range streams {
+			list = append(list, s.Name)
+		}
+		return strings.Join(list, ",")
+	}
+	streams := func() []stream.Stream {
+		return []stream.Stream{
+			build("a", 5, "720p", 3),
+			build("b", 50, "1080p", 1),
+			build("c", 20, "4K", 2),
+		}
+	}
+
+	ta := &TorBoxStremioAddon{}
+	tests := []struct {
+		mode string
+		want string
+	}{
+		{SortSize, "a,c,b"},
+		{SortSeeders, "b,c,a"},
+		{SortQuality, "c,b,a"},
+	}
+	for _, tt := range tests {
+		sorted := streams()
+		ta.sortStreams(sorted, tt.mode)
+		if got := names(sorted); got != tt.want {
+			t.Errorf("sortStreams(%s) = %s, want %s", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestPrewarmIsTrackedAndStopsOnShutdown(t *testing.T) {
+	addon := newTestAddon(t, map[string]string{"JACKETT_URL": "http://127.0.0.1:1", "EPISODE_LOOKAHEAD": "2"})
+	addon.debridClient = &fakeProvider{}
+	files := []debrid.CachedFileInfo{
+		{Name: "Show.S01E01.mkv", ID: 1},
+		{Name: "Show.S01E02.mkv", ID: 2},
+		{Name: "Show.S01E03.mkv", ID: 3},
+	}
+	req := stream.StreamRequest{Type: "series", ID: "tt0903747", Season: 1, Episode: 1}
+
+	addon.prewarmNextEpisodes(files, "T", req)
+	addon.backgroundWork.Wait()
+	for _, file := range files[1:] {
+		if _, found := types.CacheGet[string](addon.cache, prewarmedLinkKey(file.FileID("T"))); !found {
+			t.Errorf("%s not pre-warmed once the background work finished", file.Name)
+		}
+	}
+
+	// Nothing starts once the addon is shutting down
+	addon.stopBackgroundWork()
+	addon.cache.Clear()
+	req.Episode = 2
+	addon.prewarmNextEpisodes(files, "T", req)
+	addon.backgroundWork.Wait()
+	if _, found := types.CacheGet[string](addon.cache, prewarmedLinkKey(files[2].FileID("T"))); found {
+		t.Error("pre-warmed an episode after shutdown")
+	}
+}
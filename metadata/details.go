@@ -0,0 +1,256 @@
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+type TMDBMovieDetails struct {
+	ID           int    `json:"id"`
+	Title        string `json:"title"`
+	ReleaseDate  string `json:"release_date"`
+	Runtime      int    `json:"runtime"`
+	PosterPath   string `json:"poster_path,omitempty"`
+	BackdropPath string `json:"backdrop_path,omitempty"`
+}
+
+type TMDBEpisodeDetails struct {
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	Overview      string `json:"overview"`
+	AirDate       string `json:"air_date"`
+	SeasonNumber  int    `json:"season_number"`
+	EpisodeNumber int    `json:"episode_number"`
+	Runtime       int    `json:"runtime"`
+	StillPath     string `json:"still_path"`
+}
+
+// TMDBSeasonDetails is a season with all of its episodes
+type TMDBSeasonDetails struct {
+	ID           int                  `json:"id"`
+	Name         string               `json:"name"`
+	SeasonNumber int                  `json:"season_number"`
+	AirDate      string               `json:"air_date"`
+	Episodes     []TMDBEpisodeDetails `json:"episodes"`
+}
+
+type seasonCacheEntry struct {
+	season    TMDBSeasonDetails
+	expiresAt time.Time
+}
+
+// seasonCache caches whole seasons by TMDB show ID and season number
+type seasonCache struct {
+	mu    sync.RWMutex
+	items map[string]seasonCacheEntry
+}
+
+func (c *seasonCache) get(key string) (TMDBSeasonDetails, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, exists := c.items[key]
+	if !exists || time.Now().After(entry.expiresAt) {
+		return TMDBSeasonDetails{}, false
+	}
+	return entry.season, true
+}
+
+func (c *seasonCache) set(key string, season TMDBSeasonDetails, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Drop expired entries while we hold the lock
+	now := time.Now()
+	for k, entry := range c.items {
+		if now.After(entry.expiresAt) {
+			delete(c.items, k)
+		}
+	}
+
+	c.items[key] = seasonCacheEntry{season: season, expiresAt: now.Add(ttl)}
+}
+
+type runtimeCacheEntry struct {
+	runtime   int
+	expiresAt time.Time
+}
+
+// runtimeCache caches runtimes in minutes by IMDb ID (and season/episode for series)
+type runtimeCache struct {
+	mu    sync.RWMutex
+	items map[string]runtimeCacheEntry
+}
+
+func (c *runtimeCache) get(key string) (int, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, exists := c.items[key]
+	if !exists || time.Now().After(entry.expiresAt) {
+		return 0, false
+	}
+	return entry.runtime, true
+}
+
+func (c *runtimeCache) set(key string, runtime int, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Drop expired entries while we hold the lock
+	now := time.Now()
+	for k, entry := range c.items {
+		if now.After(entry.expiresAt) {
+			delete(c.items, k)
+		}
+	}
+
+	c.items[key] = runtimeCacheEntry{runtime: runtime, expiresAt: now.Add(ttl)}
+}
+
+// getJSON performs a TMDB GET request and decodes the response into target
+func (mp *Provider) getJSON(ctx context.Context, path string, target interface{}) error {
+	params := url.Values{}
+	params.Set("api_key", mp.tmdbAPIKey)
+	params.Set("language", "en-US")
+
+	fullURL := mp.apiURL + path + "?" + params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Add user agent
+	req.Header.Set("User-Agent", "TorBox-Stremio-Addon/1.0")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := mp.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("TMDB API key is invalid")
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return fmt.Errorf("TMDB rate limit exceeded")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("TMDB API error: status %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
+
+// GetMovieDetails fetches movie details by TMDB ID
+func (mp *Provider) GetMovieDetails(ctx context.Context, id string) (TMDBMovieDetails, error) {
+	var details TMDBMovieDetails
+	if err := mp.getJSON(ctx, "/movie/"+url.PathEscape(id), &details); err != nil {
+		return TMDBMovieDetails{}, err
+	}
+	return details, nil
+}
+
+// GetEpisodeDetails fetches a single episode by TMDB show ID
+func (mp *Provider) GetEpisodeDetails(ctx context.Context, id string, season, episode int) (TMDBEpisodeDetails, error) {
+	path := fmt.Sprintf("/tv/%s/season/%d/episode/%d", url.PathEscape(id), season, episode)
+
+	var details TMDBEpisodeDetails
+	if err := mp.getJSON(ctx, path, &details); err != nil {
+		return TMDBEpisodeDetails{}, err
+	}
+	return details, nil
+}
+
+// GetSeasonDetails fetches all episodes of a season in one call by TMDB show ID,
+// with air dates, titles, overviews and stills. Whole seasons are cached.
+func (mp *Provider) GetSeasonDetails(ctx context.Context, id string, season int) (TMDBSeasonDetails, error) {
+	cacheKey := fmt.Sprintf("%s:%d", id, season)
+	if details, found := mp.seasons.get(cacheKey); found {
+		return details, nil
+	}
+
+	path := fmt.Sprintf("/tv/%s/season/%d", url.PathEscape(id), season)
+
+	var details TMDBSeasonDetails
+	if err := mp.getJSON(ctx, path, &details); err != nil {
+		return TMDBSeasonDetails{}, err
+	}
+
+	mp.seasons.set(cacheKey, details, mp.cacheTTL)
+	return details, nil
+}
+
+// Episode returns an episode of the season
+func (season TMDBSeasonDetails) Episode(episode int) (TMDBEpisodeDetails, bool) {
+	for _, e := range season.Episodes {
+		if e.EpisodeNumber == episode {
+			return e, true
+		}
+	}
+	return TMDBEpisodeDetails{}, false
+}
+
+// GetRuntime returns the runtime in minutes of a movie, or of an episode when episode > 0
+func (mp *Provider) GetRuntime(ctx context.Context, imdbID string, season, episode int) (int, error) {
+	cacheKey := imdbID
+	if episode > 0 {
+		// The season is always in the key, so specials (season 0) don't share the series key
+		cacheKey = fmt.Sprintf("%s:s%d:e%d", imdbID, season, episode)
+	}
+
+	runtime, found := mp.runtimes.get(cacheKey)
+	if found {
+		return runtime, nil
+	}
+
+	meta, err := mp.GetMetadataFromTMDB(imdbID)
+	if err != nil {
+		return 0, err
+	}
+	if meta.ID == "" {
+		return 0, fmt.Errorf("no TMDB ID for %s", imdbID)
+	}
+
+	if meta.Type == "series" {
+		// The cached season serves the runtime of every episode of a binge
+		seasonDetails, err := mp.GetSeasonDetails(ctx, meta.ID, season)
+		if err != nil {
+			return 0, err
+		}
+		details, found := seasonDetails.Episode(episode)
+		if !found {
+			return 0, fmt.Errorf("episode %d not in season %d of %s", episode, season, imdbID)
+		}
+		runtime = details.Runtime
+	} else {
+		details, err := mp.GetMovieDetails(ctx, meta.ID)
+		if err != nil {
+			return 0, err
+		}
+		runtime = details.Runtime
+	}
+
+	if runtime <= 0 {
+		return 0, fmt.Errorf("runtime unknown for %s", cacheKey)
+	}
+
+	mp.runtimes.set(cacheKey, runtime, mp.cacheTTL)
+
+	log.Printf("⏱️ Runtime for %s: %d min", cacheKey, runtime)
+	return runtime, nil
+}
@@ -0,0 +1,46 @@
+package metadata
+
+import (
+	"encoding/gob"
+	"strings"
+)
+
+const imageBaseURL = "https://image.tmdb.org/t/p/"
+
+// Artwork holds the poster and background URLs of a title
+type Artwork struct {
+	Poster     string
+	Background string
+}
+
+func init() {
+	// Cloud catalog artwork is cached by torrent hash
+	gob.Register(Artwork{})
+}
+
+// ImageURL builds a full TMDB image URL from a poster/backdrop path, e.g. ImageURL("/abc.jpg", "w500")
+func ImageURL(path, size string) string {
+	if path == "" {
+		return ""
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return imageBaseURL + size + path
+}
+
+// ArtworkFromMovie returns the artwork URLs of a TMDB movie
+func ArtworkFromMovie(movie TMDBMovie) Artwork {
+	return Artwork{
+		Poster:     ImageURL(movie.PosterPath, "w500"),
+		Background: ImageURL(movie.BackdropPath, "original"),
+	}
+}
+
+// ArtworkFromShow returns the artwork URLs of a TMDB show
+func ArtworkFromShow(show TMDBShow) Artwork {
+	return Artwork{
+		Poster:     ImageURL(show.PosterPath, "w500"),
+		Background: ImageURL(show.BackdropPath, "original"),
+	}
+}
@@ -5,4 +5,6 @@ type TMDBMovie struct {
 	Title         string `json:"title"`
 	OriginalTitle string `json:"original_title"`
 	ReleaseDate   string `json:"release_date"`
+	PosterPath    string `json:"poster_path,omitempty"`
+	BackdropPath  string `json:"backdrop_path,omitempty"`
 }
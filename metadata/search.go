@@ -0,0 +1,168 @@
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SearchResult is a movie or show returned by a title search
+type SearchResult struct {
+	ID        int
+	Title     string
+	Year      string
+	MediaType string // "movie" or "series"
+	Artwork   Artwork
+}
+
+type searchCacheEntry struct {
+	results   []SearchResult
+	expiresAt time.Time
+}
+
+// searchCache caches title searches by media type and query
+type searchCache struct {
+	mu    sync.RWMutex
+	items map[string]searchCacheEntry
+}
+
+func (c *searchCache) get(key string) ([]SearchResult, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, exists := c.items[key]
+	if !exists || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.results, true
+}
+
+func (c *searchCache) set(key string, results []SearchResult, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Drop expired entries while we hold the lock
+	now := time.Now()
+	for k, entry := range c.items {
+		if now.After(entry.expiresAt) {
+			delete(c.items, k)
+		}
+	}
+
+	c.items[key] = searchCacheEntry{results: results, expiresAt: now.Add(ttl)}
+}
+
+// SearchByTitle searches TMDB by free-text title, mediaType is "movie" or "series"/"tv"
+func (mp *Provider) SearchByTitle(ctx context.Context, query, mediaType string) ([]SearchResult, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("empty search query")
+	}
+
+	var endpoint string
+	switch mediaType {
+	case "movie":
+		endpoint = "movie"
+	case "series", "tv":
+		endpoint = "tv"
+		mediaType = "series"
+	default:
+		return nil, fmt.Errorf("unsupported media type: %s", mediaType)
+	}
+
+	cacheKey := mediaType + ":" + strings.ToLower(query)
+	if results, found := mp.searchCache.get(cacheKey); found {
+		log.Printf("📦 Cache hit for TMDB search: %s", query)
+		return results, nil
+	}
+
+	params := url.Values{}
+	params.Set("api_key", mp.tmdbAPIKey)
+	params.Set("query", query)
+	params.Set("language", "en-US")
+
+	fullURL := fmt.Sprintf("%s/search/%s?%s", mp.apiURL, endpoint, params.Encode())
+
+	log.Printf("🔍 Searching TMDB %s for '%s'", endpoint, query)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Add user agent
+	req.Header.Set("User-Agent", "TorBox-Stremio-Addon/1.0")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := mp.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("TMDB API key is invalid")
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, fmt.Errorf("TMDB rate limit exceeded")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("TMDB API error: status %d", resp.StatusCode)
+	}
+
+	var results []SearchResult
+	if endpoint == "movie" {
+		var response struct {
+			Results []TMDBMovie `json:"results"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+		for _, movie := range response.Results {
+			results = append(results, SearchResult{
+				ID:        movie.ID,
+				Title:     movie.Title,
+				Year:      yearFromDate(movie.ReleaseDate),
+				MediaType: mediaType,
+				Artwork:   ArtworkFromMovie(movie),
+			})
+		}
+	} else {
+		var response struct {
+			Results []TMDBShow `json:"results"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+		for _, show := range response.Results {
+			results = append(results, SearchResult{
+				ID:        show.ID,
+				Title:     show.Name,
+				Year:      yearFromDate(show.FirstAirDate),
+				MediaType: mediaType,
+				Artwork:   ArtworkFromShow(show),
+			})
+		}
+	}
+
+	mp.searchCache.set(cacheKey, results, mp.cacheTTL)
+
+	log.Printf("✅ TMDB search for '%s' returned %d results", query, len(results))
+	return results, nil
+}
+
+// yearFromDate extracts the year from a TMDB date (format: YYYY-MM-DD)
+func yearFromDate(date string) string {
+	if len(date) >= 4 {
+		return date[:4]
+	}
+	return ""
+}
@@ -14,22 +14,77 @@ type TMDBShow struct {
 	Name         string `json:"name"`
 	OriginalName string `json:"original_name"`
 	FirstAirDate string `json:"first_air_date"`
+	PosterPath   string `json:"poster_path,omitempty"`
+	BackdropPath string `json:"backdrop_path,omitempty"`
 }
 
 type TMDBShowDetails struct {
-	Status          string `json:"status_message,omitempty"`
-	ID              int    `json:"id,omitempty"`
-	Name            string `json:"name,omitempty"`
-	OriginalName    string `json:"original_name,omitempty"`
-	FirstAirDate    string `json:"first_air_date,omitempty"`
-	NumberOfSeasons int    `json:"number_of_seasons,omitempty"`
+	Status          string              `json:"status_message,omitempty"`
+	ID              int                 `json:"id,omitempty"`
+	Name            string              `json:"name,omitempty"`
+	OriginalName    string              `json:"original_name,omitempty"`
+	FirstAirDate    string              `json:"first_air_date,omitempty"`
+	NumberOfSeasons int                 `json:"number_of_seasons,omitempty"`
+	PosterPath      string              `json:"poster_path,omitempty"`
+	BackdropPath    string              `json:"backdrop_path,omitempty"`
+	Overview        string              `json:"overview,omitempty"`
+	VoteAverage     float64             `json:"vote_average,omitempty"`
+	Seasons         []TMDBSeasonSummary `json:"seasons,omitempty"`
 	Year            string
 }
 
+// TMDBSeasonSummary is a season entry of the show details
+type TMDBSeasonSummary struct {
+	SeasonNumber int `json:"season_number"`
+	EpisodeCount int `json:"episode_count"`
+}
+
+// AbsoluteEpisode converts a season/episode to the absolute episode number used by
+// anime releases, e.g. S02E05 after a 24 episode first season is episode 29.
+// Specials (season 0) are not counted. Returns 0 if the season counts are unknown.
+func (details TMDBShowDetails) AbsoluteEpisode(season, episode int) int {
+	if season < 1 || episode < 1 {
+		return 0
+	}
+
+	counts := make(map[int]int, len(details.Seasons))
+	for _, s := range details.Seasons {
+		counts[s.SeasonNumber] = s.EpisodeCount
+	}
+
+	absolute := episode
+	for s := 1; s < season; s++ {
+		count, ok := counts[s]
+		if !ok {
+			return 0
+		}
+		absolute += count
+	}
+	return absolute
+}
+
+// GetAbsoluteEpisode returns the absolute episode number of an IMDb series episode
+func (mp *Provider) GetAbsoluteEpisode(imdbID string, season, episode int) (int, error) {
+	meta, err := mp.GetMetadataFromTMDB(imdbID)
+	if err != nil {
+		return 0, err
+	}
+
+	details, err := mp.GetTVShowDetails(meta.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	absolute := details.AbsoluteEpisode(season, episode)
+	if absolute == 0 {
+		return 0, fmt.Errorf("season episode counts unknown for %s", imdbID)
+	}
+	return absolute, nil
+}
+
 func (mp *Provider) GetTVShowDetails(id string) (tvShow TMDBShowDetails, err error) {
 	// TMDB Find endpoint - finds movies/shows by external ID (IMDb)
-	apiURL := fmt.Sprintf(
-		"https://api.themoviedb.org/3/tv/%s",
+	apiURL := fmt.Sprintf("%s/tv/%s", mp.apiURL,
 		url.QueryEscape(id),
 	)
 
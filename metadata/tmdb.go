@@ -17,55 +17,126 @@ import (
 type IMDbID struct {
 	IMDbID string `json:"imdb_id"`
 }
+
+// DefaultAPIURL is the TMDB API root
+const DefaultAPIURL = "https://api.themoviedb.org/3"
+
 type Provider struct {
-	tmdbAPIKey string
-	client     *http.Client
-	cache      *Cache
-	cacheTTL   time.Duration
+	apiURL      string
+	tmdbAPIKey  string
+	client      *http.Client
+	cache       *Cache
+	searchCache *searchCache
+	runtimes    *runtimeCache
+	seasons     *seasonCache
+	cacheTTL    time.Duration
 }
 
 type Cache struct {
 	mu    sync.RWMutex
 	items map[string]*CachedMetadata
+	// byType counts the entries per media type, kept up to date so stats don't walk items
+	byType map[string]int
 }
 
 type CachedMetadata struct {
-	Title     string
-	Year      string
-	Type      string // "movie" or "series"
-	ID        string
-	ExpiresAt time.Time
+	Title string
+	// OriginalTitle is the title in the original language, e.g. "Sen to Chihiro no Kamikakushi"
+	OriginalTitle string
+	Year          string
+	Type          string // "movie" or "series"
+	ID            string
+	ExpiresAt     time.Time
 }
 
-func NewMetadataProvider(tmdbAPIKey string, cacheTTL time.Duration) *Provider {
+// NewMetadataProvider creates a TMDB provider caching metadata for cacheTTL and removing
+// expired entries every cleanupInterval (default 5 minutes)
+func NewMetadataProvider(tmdbAPIKey string, cacheTTL, cleanupInterval time.Duration) *Provider {
 	if cacheTTL == 0 {
 		cacheTTL = 24 * time.Hour // Default to 24 hours
 	}
+	if cleanupInterval <= 0 {
+		cleanupInterval = 5 * time.Minute
+	}
 
 	mp := &Provider{
+		apiURL:     DefaultAPIURL,
 		tmdbAPIKey: tmdbAPIKey,
 		client: &http.Client{
 			Timeout: 10 * time.Second,
 		},
 		cache: &Cache{
-			items: make(map[string]*CachedMetadata),
+			items:  make(map[string]*CachedMetadata),
+			byType: make(map[string]int),
+		},
+		searchCache: &searchCache{
+			items: make(map[string]searchCacheEntry),
+		},
+		runtimes: &runtimeCache{
+			items: make(map[string]runtimeCacheEntry),
+		},
+		seasons: &seasonCache{
+			items: make(map[string]seasonCacheEntry),
 		},
 		cacheTTL: cacheTTL,
 	}
 
 	// Start cache cleanup goroutine
-	mp.cache.StartCleanup(1 * time.Hour)
+	mp.cache.StartCleanup(cleanupInterval)
 
 	return mp
 }
 
+// GetCacheStats returns the metadata cache statistics, see Cache.GetCacheStats
+func (mp *Provider) GetCacheStats(verbose bool) map[string]interface{} {
+	return mp.cache.GetCacheStats(verbose)
+}
+
+// SetAPIURL points the provider at another TMDB API root, e.g. a proxy or a fake server
+func (mp *Provider) SetAPIURL(apiURL string) {
+	if apiURL != "" {
+		mp.apiURL = strings.TrimSuffix(apiURL, "/")
+	}
+}
+
 // TMDB API response structures
 type TMDBFindResponse struct {
 	MovieResults []TMDBMovie `json:"movie_results"`
 	TVResults    []TMDBShow  `json:"tv_results"`
+
+	// Other kinds of match, which have no streams
+	PersonResults    []json.RawMessage `json:"person_results"`
+	TVEpisodeResults []json.RawMessage `json:"tv_episode_results"`
+	TVSeasonResults  []json.RawMessage `json:"tv_season_results"`
+}
+
+// otherMatches describes the non-movie, non-show matches of a find response, e.g. "1 person"
+func (r TMDBFindResponse) otherMatches() string {
+	var parts []string
+	for _, other := range []struct {
+		label string
+		count int
+	}{
+		{"person", len(r.PersonResults)},
+		{"episode", len(r.TVEpisodeResults)},
+		{"season", len(r.TVSeasonResults)},
+	} {
+		if other.count > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", other.count, other.label))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// baseIMDbID strips a Stremio ":season:episode" suffix, which the TMDB find endpoint would 404 on
+func baseIMDbID(id string) string {
+	base, _, _ := strings.Cut(id, ":")
+	return base
 }
 
 func (mp *Provider) GetTitleFromIMDb(imdbID string) (string, error) {
+	imdbID = baseIMDbID(imdbID)
+
 	// Validate IMDb ID format
 	if !strings.HasPrefix(imdbID, "tt") || len(imdbID) < 4 {
 		return imdbID, fmt.Errorf("invalid IMDb ID format: %s", imdbID)
@@ -79,9 +150,9 @@ func (mp *Provider) GetTitleFromIMDb(imdbID string) (string, error) {
 
 	// Try TMDB
 	if mp.tmdbAPIKey != "" {
-		title, mediaType, year, id, err := mp.getTitleFromTMDB(imdbID)
+		title, original, mediaType, year, id, err := mp.getTitleFromTMDB(imdbID)
 		if err == nil && title != "" {
-			mp.cache.Set(imdbID, title, year, mediaType, strconv.Itoa(id), mp.cacheTTL)
+			mp.cache.Set(imdbID, title, original, year, mediaType, strconv.Itoa(id), mp.cacheTTL)
 			log.Printf("✅ Found title for %s: %s (%s)", imdbID, title, year)
 			return title, nil
 		}
@@ -92,10 +163,11 @@ func (mp *Provider) GetTitleFromIMDb(imdbID string) (string, error) {
 	return imdbID, fmt.Errorf("unable to fetch title for %s", imdbID)
 }
 
-func (mp *Provider) getTitleFromTMDB(imdbID string) (title, mediaType, year string, id int, err error) {
+func (mp *Provider) getTitleFromTMDB(imdbID string) (title, original, mediaType, year string, id int, err error) {
+	imdbID = baseIMDbID(imdbID)
+
 	// TMDB Find endpoint - finds movies/shows by external ID (IMDb)
-	apiURL := fmt.Sprintf(
-		"https://api.themoviedb.org/3/find/%s",
+	apiURL := fmt.Sprintf("%s/find/%s", mp.apiURL,
 		url.QueryEscape(imdbID),
 	)
 
@@ -111,7 +183,7 @@ func (mp *Provider) getTitleFromTMDB(imdbID string) (title, mediaType, year stri
 
 	req, err := http.NewRequest(http.MethodGet, fullURL, nil)
 	if err != nil {
-		return "", "", "", 0, fmt.Errorf("failed to create request: %w", err)
+		return "", "", "", "", 0, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Add user agent
@@ -120,7 +192,7 @@ func (mp *Provider) getTitleFromTMDB(imdbID string) (title, mediaType, year stri
 
 	resp, err := mp.client.Do(req)
 	if err != nil {
-		return "", "", "", 0, fmt.Errorf("request failed: %w", err)
+		return "", "", "", "", 0, fmt.Errorf("request failed: %w", err)
 	}
 	defer func(Body io.ReadCloser) {
 		err := Body.Close()
@@ -129,20 +201,20 @@ func (mp *Provider) getTitleFromTMDB(imdbID string) (title, mediaType, year stri
 	}(resp.Body)
 
 	if resp.StatusCode == http.StatusUnauthorized {
-		return "", "", "", 0, fmt.Errorf("TMDB API key is invalid")
+		return "", "", "", "", 0, fmt.Errorf("TMDB API key is invalid")
 	}
 
 	if resp.StatusCode == http.StatusTooManyRequests {
-		return "", "", "", 0, fmt.Errorf("TMDB rate limit exceeded")
+		return "", "", "", "", 0, fmt.Errorf("TMDB rate limit exceeded")
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", "", "", 0, fmt.Errorf("TMDB API error: status %d", resp.StatusCode)
+		return "", "", "", "", 0, fmt.Errorf("TMDB API error: status %d", resp.StatusCode)
 	}
 
 	var result TMDBFindResponse
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", "", "", 0, fmt.Errorf("failed to decode response: %w", err)
+		return "", "", "", "", 0, fmt.Errorf("failed to decode response: %w", err)
 	}
 
 	// Check movie results first
@@ -157,7 +229,7 @@ func (mp *Provider) getTitleFromTMDB(imdbID string) (title, mediaType, year stri
 		}
 
 		log.Printf("✅ Found movie: %s (%s)", title, year)
-		return title, mediaType, year, movie.ID, nil
+		return title, movie.OriginalTitle, mediaType, year, movie.ID, nil
 	}
 
 	// Check TV show results
@@ -172,33 +244,43 @@ func (mp *Provider) getTitleFromTMDB(imdbID string) (title, mediaType, year stri
 		}
 
 		log.Printf("✅ Found TV show: %s (%s)", title, year)
-		return title, mediaType, year, show.ID, nil
+		return title, show.OriginalName, mediaType, year, show.ID, nil
+	}
+
+	// The ID belongs to something that isn't a title, say so instead of a bare "no results"
+	if others := result.otherMatches(); others != "" {
+		log.Printf("⚠️  TMDB matched %s to %s, not a movie or TV show", imdbID, others)
+		return "", "", "", "", 0, fmt.Errorf("%s is not a movie or TV show on TMDB (matched %s)", imdbID, others)
 	}
 
-	return "", "", "", 0, fmt.Errorf("no results found for %s", imdbID)
+	return "", "", "", "", 0, fmt.Errorf("no results found for %s", imdbID)
 }
 
 // GetMetadataFromTMDB gets full metadata including title, year, type
 func (mp *Provider) GetMetadataFromTMDB(imdbID string) (*CachedMetadata, error) {
+	imdbID = baseIMDbID(imdbID)
+
 	// Check cache first
 	if cached := mp.cache.Get(imdbID); cached != nil {
 		return cached, nil
 	}
 
 	// Fetch from TMDB
-	title, mediaType, year, id, err := mp.getTitleFromTMDB(imdbID)
+	title, original, mediaType, year, id, err := mp.getTitleFromTMDB(imdbID)
 	if err != nil {
 		return nil, err
 	}
 
 	metadata := &CachedMetadata{
-		Title: title,
-		Year:  year,
-		Type:  mediaType,
+		Title:         title,
+		OriginalTitle: original,
+		Year:          year,
+		Type:          mediaType,
+		ID:            strconv.Itoa(id),
 	}
 
 	// Cache it
-	mp.cache.Set(imdbID, title, year, mediaType, strconv.Itoa(id), mp.cacheTTL)
+	mp.cache.Set(imdbID, title, original, year, mediaType, strconv.Itoa(id), mp.cacheTTL)
 
 	return metadata, nil
 }
@@ -206,29 +288,50 @@ func (mp *Provider) GetMetadataFromTMDB(imdbID string) (*CachedMetadata, error)
 // Cache methods
 func (c *Cache) Get(imdbID string) *CachedMetadata {
 	c.mu.RLock()
-	defer c.mu.RUnlock()
+	item, exists := c.items[imdbID]
+	c.mu.RUnlock()
+
+	if !exists {
+		return nil
+	}
+	if time.Now().Before(item.ExpiresAt) {
+		return item
+	}
+
+	// Expired, unless it was replaced meanwhile
+	c.mu.Lock()
+	if c.items[imdbID] == item {
+		c.remove(imdbID)
+	}
+	c.mu.Unlock()
+
+	return nil
+}
 
+// remove deletes an entry and its type count, c.mu must be held
+func (c *Cache) remove(imdbID string) {
 	if item, exists := c.items[imdbID]; exists {
-		if time.Now().Before(item.ExpiresAt) {
-			return item
+		c.byType[item.Type]--
+		if c.byType[item.Type] <= 0 {
+			delete(c.byType, item.Type)
 		}
-		// Expired
 		delete(c.items, imdbID)
 	}
-
-	return nil
 }
 
-func (c *Cache) Set(imdbID, title, year, mediaType string, id string, ttl time.Duration) {
+func (c *Cache) Set(imdbID, title, original, year, mediaType string, id string, ttl time.Duration) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	c.remove(imdbID)
+	c.byType[mediaType]++
 	c.items[imdbID] = &CachedMetadata{
-		Title:     title,
-		Year:      year,
-		Type:      mediaType,
-		ID:        id,
-		ExpiresAt: time.Now().Add(ttl),
+		Title:         title,
+		OriginalTitle: original,
+		Year:          year,
+		Type:          mediaType,
+		ID:            id,
+		ExpiresAt:     time.Now().Add(ttl),
 	}
 }
 
@@ -237,6 +340,7 @@ func (c *Cache) Clear() {
 	defer c.mu.Unlock()
 
 	c.items = make(map[string]*CachedMetadata)
+	c.byType = make(map[string]int)
 }
 
 // StartCleanup starts periodic cleanup of expired cache entries
@@ -257,7 +361,7 @@ func (c *Cache) cleanup() {
 	count := 0
 	for id, item := range c.items {
 		if now.After(item.ExpiresAt) {
-			delete(c.items, id)
+			c.remove(id)
 			count++
 		}
 	}
@@ -267,16 +371,25 @@ func (c *Cache) cleanup() {
 	}
 }
 
-// GetCacheStats returns cache statistics
-func (c *Cache) GetCacheStats() map[string]interface{} {
+// GetCacheStats returns cache statistics from the running counters. verbose also lists
+// every entry, which walks the whole cache and holds up writers meanwhile.
+func (c *Cache) GetCacheStats(verbose bool) map[string]interface{} {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
+	byType := make(map[string]int, len(c.byType))
+	for mediaType, count := range c.byType {
+		byType[mediaType] = count
+	}
 	stats := map[string]interface{}{
 		"total_entries": len(c.items),
-		"entries":       []map[string]string{},
+		"by_type":       byType,
+	}
+	if !verbose {
+		return stats
 	}
 
+	stats["entries"] = []map[string]string{}
 	for id, item := range c.items {
 		stats["entries"] = append(stats["entries"].([]map[string]string), map[string]string{
 			"imdb_id": id,
@@ -291,8 +404,7 @@ func (c *Cache) GetCacheStats() map[string]interface{} {
 
 func (mp *Provider) GetIMDbID(ctx context.Context, mediaType, id string) (string, error) {
 	// TMDB Find endpoint - finds movies/shows by external ID (IMDb)
-	apiURL := fmt.Sprintf(
-		"https://api.themoviedb.org/3/%s/%s/external_ids",
+	apiURL := fmt.Sprintf("%s/%s/%s/external_ids", mp.apiURL,
 		url.QueryEscape(mediaType),
 		url.QueryEscape(id),
 	)
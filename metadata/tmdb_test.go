@@ -0,0 +1,177 @@
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newFakeTMDB serves body for every request and points a provider at it
+func newFakeTMDB(t *testing.T, body string) *Provider {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+
+	mp := NewMetadataProvider("tmdb-key", time.Hour, 0)
+	mp.SetAPIURL(server.URL)
+	return mp
+}
+
+func TestGetMetadataPersonOnlyFind(t *testing.T) {
+	mp := newFakeTMDB(t, `{"movie_results":[],"tv_results":[],
+		"person_results":[{"id":287,"name":"Brad Pitt","known_for_department":"Acting"}],
+		"tv_episode_results":[],"tv_season_results":[]}`)
+
+	meta, err := mp.GetMetadataFromTMDB("tt0000093")
+	if err == nil {
+		t.Fatalf("GetMetadataFromTMDB = %+v, want an error for a person", meta)
+	}
+	if !strings.Contains(err.Error(), "not a movie or TV show") || !strings.Contains(err.Error(), "1 person") {
+		t.Errorf("error = %q, want it to name the person match", err)
+	}
+}
+
+func TestOtherMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		response TMDBFindResponse
+		want     string
+	}{
+		{"none", TMDBFindResponse{}, ""},
+		{"person", TMDBFindResponse{PersonResults: make([]json.RawMessage, 1)}, "1 person"},
+		{"episode and seasons", TMDBFindResponse{
+			TVEpisodeResults: make([]json.RawMessage, 1),
+			TVSeasonResults:  make([]json.RawMessage, 2),
+		}, "1 episode, 2 season"},
+	}
+	for _, tt := range tests {
+		if got := tt.response.otherMatches(); got != tt.want {
+			t.Errorf("%s: otherMatches() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBaseIMDbID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"tt0111161", "tt0111161"},
+		{"tt0944947:1:5", "tt0944947"},
+		{"tt0944947:1", "tt0944947"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := baseIMDbID(tt.id); got != tt.want {
+			t.Errorf("baseIMDbID(%q) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestGetMetadataStripsEpisodeSuffix(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		fmt.Fprint(w, `{"movie_results":[],"tv_results":[{"id":1399,"name":"Game of Thrones","first_air_date":"2011-04-17"}]}`)
+	}))
+	t.Cleanup(server.Close)
+
+	mp := NewMetadataProvider("tmdb-key", time.Hour, 0)
+	mp.SetAPIURL(server.URL)
+
+	for _, id := range []string{"tt0944947:1:5", "tt0944947"} {
+		meta, err := mp.GetMetadataFromTMDB(id)
+		if err != nil {
+			t.Fatalf("GetMetadataFromTMDB(%q): %v", id, err)
+		}
+		if meta.ID != "1399" {
+			t.Errorf("GetMetadataFromTMDB(%q).ID = %q, want 1399", id, meta.ID)
+		}
+	}
+
+	// The suffixed ID is looked up bare, and the second call is a cache hit
+	if len(paths) != 1 || paths[0] != "/find/tt0944947" {
+		t.Errorf("requested %v, want a single /find/tt0944947", paths)
+	}
+}
+
+func TestGetRuntimeKeepsSpecialsApart(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/find/tt0436992":
+			fmt.Fprint(w, `{"movie_results":[],"tv_results":[{"id":57243,"name":"Doctor Who","first_air_date":"2005-03-26"}]}`)
+		case "/tv/57243/season/0":
+			fmt.Fprint(w, `{"season_number":0,"episodes":[{"episode_number":1,"season_number":0,"runtime":60},
+				{"episode_number":2,"season_number":0,"runtime":30}]}`)
+		case "/tv/57243/season/1":
+			fmt.Fprint(w, `{"season_number":1,"episodes":[{"episode_number":1,"season_number":1,"runtime":45}]}`)
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	mp := NewMetadataProvider("tmdb-key", time.Hour, 0)
+	mp.SetAPIURL(server.URL)
+
+	for _, tt := range []struct {
+		season, episode, want int
+	}{
+		{0, 1, 60},
+		{0, 2, 30},
+		{1, 1, 45},
+		// Cached, still per season
+		{0, 1, 60},
+	} {
+		got, err := mp.GetRuntime(context.Background(), "tt0436992", tt.season, tt.episode)
+		if err != nil || got != tt.want {
+			t.Errorf("GetRuntime(S%02dE%02d) = %d, %v, want %d", tt.season, tt.episode, got, err, tt.want)
+		}
+	}
+}
+
+func TestCleanupIntervalIsConfigurable(t *testing.T) {
+	mp := NewMetadataProvider("tmdb-key", time.Hour, 10*time.Millisecond)
+	mp.cache.Set("tt0111161", "The Shawshank Redemption", "", "1994", "movie", "278", time.Millisecond)
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		mp.cache.mu.RLock()
+		n := len(mp.cache.items)
+		mp.cache.mu.RUnlock()
+		if n == 0 {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Error("expired entry still cached after a second with a 10ms cleanup interval")
+}
+
+func TestRuntimeCacheExpires(t *testing.T) {
+	c := &runtimeCache{items: make(map[string]runtimeCacheEntry)}
+
+	c.set("tt0111161", 142, time.Hour)
+	if got, found := c.get("tt0111161"); !found || got != 142 {
+		t.Errorf("get() = %d, %v, want 142", got, found)
+	}
+
+	c.set("tt0068646", 175, -time.Second)
+	if _, found := c.get("tt0068646"); found {
+		t.Error("get() found an expired runtime")
+	}
+
+	// Setting another runtime drops the expired ones
+	c.set("tt0071562", 202, time.Hour)
+	if _, ok := c.items["tt0068646"]; ok {
+		t.Error("expired runtime still stored after set")
+	}
+}
@@ -23,7 +23,7 @@ type TMDBTrendingItem struct {
 }
 
 func (mp *Provider) FetchTrendingMovies(ctx context.Context) ([]TMDBTrendingItem, error) {
-	url := fmt.Sprintf("https://api.themoviedb.org/3/trending/movie/week?api_key=%s", mp.tmdbAPIKey)
+	url := fmt.Sprintf("%s/trending/movie/week?api_key=%s", mp.apiURL, mp.tmdbAPIKey)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -50,7 +50,7 @@ func (mp *Provider) FetchTrendingMovies(ctx context.Context) ([]TMDBTrendingItem
 }
 
 func (mp *Provider) FetchTrendingTV(ctx context.Context) ([]TMDBTrendingItem, error) {
-	url := fmt.Sprintf("https://api.themoviedb.org/3/trending/tv/week?api_key=%s", mp.tmdbAPIKey)
+	url := fmt.Sprintf("%s/trending/tv/week?api_key=%s", mp.apiURL, mp.tmdbAPIKey)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
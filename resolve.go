@@ -0,0 +1,99 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"stremfy/debrid"
+	"stremfy/types"
+	"strings"
+	"time"
+)
+
+// newResolveSecret returns a random key for signing /resolve links
+func newResolveSecret() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatalf("❌ Failed to generate RESOLVE_SECRET: %v", err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// resolveSignature signs the parameters of a /resolve link
+func (ta *TorBoxStremioAddon) resolveSignature(torrentID, fileID, hash, index, expires string) string {
+	mac := hmac.New(sha256.New, []byte(ta.config.ResolveSecret))
+	fmt.Fprintf(mac, "%s|%s|%s|%s|%s", torrentID, fileID, hash, index, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// resolveURL is the signed addon link that requests the TorBox download link when played.
+// It carries the info hash and file index so a failed link can be retried like an upfront one.
+func (ta *TorBoxStremioAddon) resolveURL(hash, torrentID string, file debrid.CachedFileInfo) string {
+	fileID, index := strconv.Itoa(file.ID), strconv.Itoa(file.Index)
+	expires := strconv.FormatInt(time.Now().Add(ta.config.ResolveLinkTTL).Unix(), 10)
+
+	params := url.Values{}
+	params.Set("hash", hash)
+	params.Set("idx", index)
+	params.Set("exp", expires)
+	params.Set("sig", ta.resolveSignature(torrentID, fileID, hash, index, expires))
+
+	return fmt.Sprintf("%s/resolve/%s/%s?%s", ta.config.PublicURL, url.PathEscape(torrentID), fileID, params.Encode())
+}
+
+// handleResolve requests the download link of a signed /resolve/<torrentID>/<fileID> and redirects to it
+func (ta *TorBoxStremioAddon) handleResolve(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/resolve/"), "/")
+	if len(parts) != 2 || parts[0] == "" {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+
+	torrentID := parts[0]
+	fileID, err := strconv.Atoi(parts[1])
+	if err != nil {
+		http.Error(w, "Invalid file ID", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	hash, index, expires := query.Get("hash"), query.Get("idx"), query.Get("exp")
+	signature := ta.resolveSignature(torrentID, parts[1], hash, index, expires)
+	if !hmac.Equal([]byte(signature), []byte(query.Get("sig"))) {
+		http.Error(w, "Invalid signature", http.StatusForbidden)
+		return
+	}
+	expiry, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiry {
+		http.Error(w, "Link expired", http.StatusGone)
+		return
+	}
+	fileIndex, err := strconv.Atoi(index)
+	if err != nil {
+		http.Error(w, "Invalid file index", http.StatusBadRequest)
+		return
+	}
+
+	file := debrid.CachedFileInfo{Name: fmt.Sprintf("%s/%d", torrentID, fileID), ID: fileID, Index: fileIndex}
+	downloadURL, err := ta.unrestrictWithRetry(types.ScrapeResult{InfoHash: hash}, file, file.FileID(torrentID))
+	if err != nil {
+		log.Printf("❌ Failed to resolve %s/%d: %v", torrentID, fileID, err)
+		switch {
+		case errors.Is(err, debrid.ErrTorrentNotReady), errors.Is(err, debrid.ErrRateLimited):
+			http.Error(w, "Link not available yet", http.StatusServiceUnavailable)
+		default:
+			http.Error(w, "Failed to resolve link", http.StatusBadGateway)
+		}
+		return
+	}
+
+	log.Printf("🔗 Resolved %s/%d", torrentID, fileID)
+	http.Redirect(w, r, downloadURL, http.StatusFound)
+}
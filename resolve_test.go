@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"stremfy/debrid"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandleResolveRejectsUnsignedLinks(t *testing.T) {
+	ta := &TorBoxStremioAddon{config: Config{PublicURL: "http://addon", ResolveSecret: "secret", ResolveLinkTTL: time.Hour}}
+	signed := ta.resolveURL("abc", "42", debrid.CachedFileInfo{ID: 7, Index: 3})
+
+	expired := &TorBoxStremioAddon{config: ta.config}
+	expired.config.ResolveLinkTTL = -time.Minute
+	expiredURL := expired.resolveURL("abc", "42", debrid.CachedFileInfo{ID: 7, Index: 3})
+
+	otherKey := &TorBoxStremioAddon{config: ta.config}
+	otherKey.config.ResolveSecret = "other"
+
+	tests := []struct {
+		name string
+		link string
+		want int
+	}{
+		{"unsigned", "http://addon/resolve/42/7", http.StatusForbidden},
+		{"other file", strings.Replace(signed, "/42/7?", "/42/8?", 1), http.StatusForbidden},
+		{"other torrent", strings.Replace(signed, "/42/7?", "/43/7?", 1), http.StatusForbidden},
+		{"other hash", strings.Replace(signed, "hash=abc", "hash=abd", 1), http.StatusForbidden},
+		{"other key", otherKey.resolveURL("abc", "42", debrid.CachedFileInfo{ID: 7, Index: 3}), http.StatusForbidden},
+		{"expired", expiredURL, http.StatusGone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link, err := url.Parse(tt.link)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec := httptest.NewRecorder()
+			ta.handleResolve(rec, httptest.NewRequest(http.MethodGet, link.RequestURI(), nil))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package scrapers
+
+import (
+	"regexp"
+	"stremfy/utils"
+	"strings"
+)
+
+// adultKeywords are unambiguous adult markers, kept short to avoid false positives
+var adultKeywords = NewBlocklist([]string{"xxx", "porn", "porno", "brazzers", "bangbros", "onlyfans", "pornhub"}, nil)
+
+// isAdultResult checks the Jackett XXX categories (6000-6999) and a few unambiguous keywords
+func isAdultResult(result JackettResult) bool {
+	for _, category := range result.Category {
+		if category >= 6000 && category < 7000 {
+			return true
+		}
+	}
+	return adultKeywords.Blocked(result.Title) != ""
+}
+
+// Blocklist drops results by title keyword or release group
+type Blocklist struct {
+	keywords []*regexp.Regexp
+	groups   map[string]bool
+}
+
+// NewBlocklist compiles the keyword and group lists, empty entries are ignored
+func NewBlocklist(keywords, groups []string) *Blocklist {
+	b := &Blocklist{groups: make(map[string]bool)}
+
+	for _, keyword := range keywords {
+		keyword = strings.TrimSpace(keyword)
+		if keyword == "" {
+			continue
+		}
+		// Match whole words only so "cam" doesn't block "camera"
+		pattern := `(?i)(^|[^\pL\pN])` + regexp.QuoteMeta(keyword) + `([^\pL\pN]|$)`
+		b.keywords = append(b.keywords, regexp.MustCompile(pattern))
+	}
+
+	for _, group := range groups {
+		group = strings.ToLower(strings.TrimSpace(group))
+		if group != "" {
+			b.groups[group] = true
+		}
+	}
+
+	return b
+}
+
+// Blocked returns a short reason if the title is blocked, or "" if it is allowed
+func (b *Blocklist) Blocked(title string) string {
+	if b == nil {
+		return ""
+	}
+
+	for _, keyword := range b.keywords {
+		if keyword.MatchString(title) {
+			return "keyword"
+		}
+	}
+
+	if len(b.groups) > 0 {
+		if group := utils.ExtractReleaseGroup(title); group != "" && b.groups[strings.ToLower(group)] {
+			return "group " + group
+		}
+	}
+
+	return ""
+}
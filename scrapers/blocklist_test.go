@@ -0,0 +1,54 @@
+package scrapers
+
+import (
+	"context"
+	"stremfy/types"
+	"testing"
+)
+
+func TestBlocklist(t *testing.T) {
+	blocklist := NewBlocklist([]string{"HDCAM", " hdts ", ""}, []string{"YIFY", ""})
+
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Movie.2024.HDCAM.x264-GROUP", "keyword"},
+		{"Movie 2024 hdts 720p", "keyword"},
+		{"Movie.2024.1080p.WEB-DL.x264-yify", "group yify"},
+		{"Movie.2024.1080p.WEB-DL.x264-GROUP", ""},
+		// Keywords only match whole words
+		{"Movie.2024.HDCAMERA.1080p-GROUP", ""},
+	}
+	for _, tt := range tests {
+		if got := blocklist.Blocked(tt.title); got != tt.want {
+			t.Errorf("Blocked(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+
+	var none *Blocklist
+	if got := none.Blocked("Movie.2024.HDCAM"); got != "" {
+		t.Errorf("nil Blocked = %q, want \"\"", got)
+	}
+}
+
+func TestScrapeDropsBlockedResults(t *testing.T) {
+	jackett := newFakeJackett(t, func(query string) []JackettResult {
+		return []JackettResult{
+			{Title: "Dune Part Two 2024 HDCAM x264-GROUP", Details: "https://t/1", InfoHash: "1111111111111111111111111111111111111111"},
+			{Title: "Dune Part Two 2024 1080p WEB-DL x264-BADGRP", Details: "https://t/2", InfoHash: "2222222222222222222222222222222222222222"},
+			{Title: "Dune Part Two 2024 1080p WEB-DL x264-GOOD", Details: "https://t/3", InfoHash: "3333333333333333333333333333333333333333"},
+		}
+	})
+
+	scraper := NewJackettScraper(JackettConfig{
+		URL: jackett.URL, APIKey: "key", BlockedKeywords: []string{"hdcam"}, BlockedGroups: []string{"badgrp"},
+	})
+	torrents, err := scraper.Scrape(context.Background(), types.ScrapeRequest{Title: "Dune Part Two", MediaType: "movie", MediaOnlyID: "tt15239678"}, newFakeTorrentManager())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := hashes(torrents), []string{"3333333333333333333333333333333333333333"}; !equalStrings(got, want) {
+		t.Errorf("hashes = %v, want %v", got, want)
+	}
+}
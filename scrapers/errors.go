@@ -0,0 +1,7 @@
+package scrapers
+
+import "errors"
+
+// ErrIndexerUnavailable is returned (wrapped) when the indexer can't be reached or fails,
+// as opposed to a search that genuinely found nothing. Check it with errors.Is.
+var ErrIndexerUnavailable = errors.New("scrapers: indexer unavailable")
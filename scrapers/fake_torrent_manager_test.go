@@ -0,0 +1,149 @@
+package scrapers
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fakeTorrent describes the canned data returned for a single link
+type fakeTorrent struct {
+	InfoHash string
+	Trackers []string
+	Files    []TorrentFile
+	Cached   bool
+}
+
+// fakeTorrentManager is an in-memory TorrentManager that never touches the network.
+// Links registered with addLink resolve to canned metadata, everything else fails.
+type fakeTorrentManager struct {
+	// delay holds every download, to exercise concurrent processing
+	delay time.Duration
+	// ignoreCancel finishes held downloads even once ctx is done
+	ignoreCancel bool
+
+	mu        sync.Mutex
+	torrents  map[string]fakeTorrent
+	downloads map[string]int
+	added     []string
+	inFlight  int
+	peak      int
+}
+
+func newFakeTorrentManager() *fakeTorrentManager {
+	return &fakeTorrentManager{
+		torrents:  make(map[string]fakeTorrent),
+		downloads: make(map[string]int),
+	}
+}
+
+// addLink registers the canned torrent returned when link is downloaded
+func (f *fakeTorrentManager) addLink(link string, torrent fakeTorrent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.torrents[link] = torrent
+}
+
+func (f *fakeTorrentManager) AddTorrent(magnetURL string, seeders *int, tracker, mediaID string, season int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.added = append(f.added, magnetURL)
+	return nil
+}
+
+func (f *fakeTorrentManager) DownloadTorrent(ctx context.Context, link string) ([]byte, string, string, error) {
+	f.mu.Lock()
+	f.downloads[link]++
+	f.inFlight++
+	f.peak = max(f.peak, f.inFlight)
+	f.mu.Unlock()
+
+	defer func() {
+		f.mu.Lock()
+		f.inFlight--
+		f.mu.Unlock()
+	}()
+
+	if f.ignoreCancel {
+		time.Sleep(f.delay)
+	} else {
+		select {
+		case <-time.After(f.delay):
+		case <-ctx.Done():
+			return nil, "", "", ctx.Err()
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.torrents[link]; !ok {
+		return nil, "", "", fmt.Errorf("failed to download torrent: status %d", 404)
+	}
+
+	// The link itself is used as the "content", ExtractTorrentMetadata maps it back
+	return []byte(link), "", "", nil
+}
+
+func (f *fakeTorrentManager) ExtractTorrentMetadata(content []byte) (*TorrentMetadata, error) {
+	if len(content) == 0 {
+		return nil, fmt.Errorf("empty content")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	torrent, ok := f.torrents[string(content)]
+	if !ok {
+		return nil, fmt.Errorf("failed to decode torrent: unknown content")
+	}
+
+	return &TorrentMetadata{
+		InfoHash:     torrent.InfoHash,
+		Files:        torrent.Files,
+		AnnounceList: torrent.Trackers,
+	}, nil
+}
+
+func (f *fakeTorrentManager) ExtractTrackersFromMagnet(magnetURL string) []string {
+	_, query, _ := strings.Cut(magnetURL, "?")
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return nil
+	}
+	return params["tr"]
+}
+
+func (f *fakeTorrentManager) GetCachedTorrentFiles(ctx context.Context, hash string) ([]TorrentFile, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, torrent := range f.torrents {
+		if torrent.InfoHash == hash {
+			if !torrent.Cached {
+				return nil, false, nil
+			}
+			return torrent.Files, true, nil
+		}
+	}
+
+	return nil, false, nil
+}
+
+// downloadCount returns how many times a link was downloaded
+func (f *fakeTorrentManager) downloadCount(link string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.downloads[link]
+}
+
+// peakDownloads returns the most downloads that were in flight at once
+func (f *fakeTorrentManager) peakDownloads() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.peak
+}
+
+var _ TorrentManager = (*fakeTorrentManager)(nil)
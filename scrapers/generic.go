@@ -1,12 +1,14 @@
 package scrapers
 
 import (
+	"encoding/base32"
 	"encoding/hex"
 	"fmt"
 	"log"
 	"regexp"
 	"strconv"
 	"stremfy/types"
+	"stremfy/utils"
 	"strings"
 )
 
@@ -30,6 +32,43 @@ type ScraperManager interface {
 	// Add methods as needed
 }
 
+// SizeRange is the plausible size range (in bytes) for a quality, 0 means unbounded
+type SizeRange struct {
+	Min int64
+	Max int64
+}
+
+// DefaultSizeRanges are the movie size ranges used when none are configured
+var DefaultSizeRanges = map[string]SizeRange{
+	"4K":    {Min: 2 << 30, Max: 120 << 30},
+	"1080p": {Min: 500 << 20, Max: 60 << 30},
+	"720p":  {Min: 300 << 20, Max: 20 << 30},
+	"480p":  {Min: 100 << 20, Max: 5 << 30},
+}
+
+// IsSizeSaneForQuality rejects results whose size is wildly inconsistent with the quality in the title.
+// Series results can be season packs, so only the lower bound is enforced for them.
+func IsSizeSaneForQuality(title string, size int64, isSeries bool, ranges map[string]SizeRange) bool {
+	if size <= 0 {
+		// Unknown size, nothing to check against
+		return true
+	}
+
+	sizeRange, ok := ranges[utils.ExtractQuality(title)]
+	if !ok {
+		return true
+	}
+
+	if sizeRange.Min > 0 && size < sizeRange.Min {
+		return false
+	}
+	if !isSeries && sizeRange.Max > 0 && size > sizeRange.Max {
+		return false
+	}
+
+	return true
+}
+
 func isEpisodePack(title string, season int, episode int) bool {
 	titleLower := strings.ToLower(title)
 
@@ -288,14 +327,47 @@ func normalizeInfoHash(hash string) string {
 		hash = string(decoded)
 	}
 
-	// Validate and normalize
-	hash = strings.ToLower(hash)
-	if len(hash) != 40 {
+	// Validate and normalize (base32 hashes are converted to hex)
+	if len(hash) != 40 && len(hash) != 32 {
 		log.Printf("⚠️ Invalid hash length %d (expected 40): %s", len(hash), hash)
 		return ""
 	}
 
-	return hash
+	decoded := decodeInfoHash(hash)
+	if decoded == "" {
+		log.Printf("⚠️ Invalid hash: %s", hash)
+	}
+	return decoded
+}
+
+var magnetHashPattern = regexp.MustCompile(`(?i)xt=urn:btih:([a-z0-9]+)`)
+
+// HashFromMagnet extracts the info hash of a magnet URI as 40-char lowercase hex,
+// converting 32-char base32 hashes, or returns "" if the magnet has no valid hash
+func HashFromMagnet(magnetURI string) string {
+	matches := magnetHashPattern.FindStringSubmatch(magnetURI)
+	if len(matches) < 2 {
+		return ""
+	}
+	return decodeInfoHash(matches[1])
+}
+
+// decodeInfoHash returns a 40-char hex or 32-char base32 info hash as 40-char lowercase hex, or ""
+func decodeInfoHash(hash string) string {
+	switch len(hash) {
+	case 40:
+		if _, err := hex.DecodeString(hash); err != nil {
+			return ""
+		}
+		return strings.ToLower(hash)
+	case 32:
+		decoded, err := base32.StdEncoding.DecodeString(strings.ToUpper(hash))
+		if err != nil {
+			return ""
+		}
+		return hex.EncodeToString(decoded)
+	}
+	return ""
 }
 
 // shouldFilterSeriesResult determines if a series result should be filtered out
@@ -354,3 +426,61 @@ func isCompleteSeriesPack(title string) bool {
 
 	return false
 }
+
+var (
+	packSeasonRangePatterns = []*regexp.Regexp{
+		regexp.MustCompile(`\bs(\d{1,2})\s*-\s*s?(\d{1,2})\b`),
+		regexp.MustCompile(`\bseason\s(\d{1,2})\s*-\s*(\d{1,2})\b`),
+		regexp.MustCompile(`\btemporada\s(\d{1,2})\s*-\s*(\d{1,2})\b`),
+	}
+	packEpisodePattern       = regexp.MustCompile(`\bs\d{1,2}[\s\.]*e\d{1,3}|\b\d{1,2}x\d{2,3}\b`)
+	packSingleSeasonPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`\bs(\d{1,2})\b`),
+		regexp.MustCompile(`\bseason\s(\d{1,2})\b`),
+		regexp.MustCompile(`\btemporada\s(\d{1,2})\b`),
+	}
+)
+
+// IsPack reports whether a title is a season or complete-series pack rather than single episodes
+func IsPack(title string) bool {
+	if packEpisodePattern.MatchString(strings.ToLower(title)) {
+		return false
+	}
+	return len(PackSeasons(title, 0)) > 0 || isCompleteSeriesPack(title)
+}
+
+// PackSeasons returns the seasons covered by a season or complete-series pack title,
+// or nil if the title is a single episode or can't be classified
+func PackSeasons(title string, totalSeasons int) []int {
+	titleLower := strings.ToLower(title)
+
+	seasonRange := func(start, end int) []int {
+		var seasons []int
+		for s := start; s <= end; s++ {
+			seasons = append(seasons, s)
+		}
+		return seasons
+	}
+
+	for _, re := range packSeasonRangePatterns {
+		if matches := re.FindStringSubmatch(titleLower); len(matches) == 3 {
+			return seasonRange(parseInt(matches[1]), parseInt(matches[2]))
+		}
+	}
+
+	if packEpisodePattern.MatchString(titleLower) {
+		return nil
+	}
+
+	for _, re := range packSingleSeasonPatterns {
+		if matches := re.FindStringSubmatch(titleLower); len(matches) == 2 {
+			return []int{parseInt(matches[1])}
+		}
+	}
+
+	if isCompleteSeriesPack(title) && totalSeasons > 0 {
+		return seasonRange(1, totalSeasons)
+	}
+
+	return nil
+}
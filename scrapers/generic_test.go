@@ -0,0 +1,48 @@
+package scrapers
+
+import "testing"
+
+const (
+	hexHash    = "0123456789abcdef0123456789abcdef01234567"
+	base32Hash = "AERUKZ4JVPG66AJDIVTYTK6N54ASGRLH"
+)
+
+func TestHashFromMagnet(t *testing.T) {
+	tests := []struct {
+		magnet string
+		want   string
+	}{
+		{"magnet:?xt=urn:btih:" + hexHash + "&dn=Movie", hexHash},
+		{"magnet:?xt=urn:btih:0123456789ABCDEF0123456789ABCDEF01234567&tr=udp://t:1", hexHash},
+		{"magnet:?xt=urn:btih:" + base32Hash + "&dn=Movie", hexHash},
+		{"magnet:?dn=Movie&xt=urn:btih:aeruKZ4JVPG66AJDIVTYTK6N54ASGRLH", hexHash},
+		{"magnet:?xt=urn:btih:tooshort", ""},
+		{"magnet:?xt=urn:btih:ZZZZ456789abcdef0123456789abcdef01234567", ""},
+		{"magnet:?dn=Movie", ""},
+	}
+	for _, tt := range tests {
+		if got := HashFromMagnet(tt.magnet); got != tt.want {
+			t.Errorf("HashFromMagnet(%q) = %q, want %q", tt.magnet, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeInfoHash(t *testing.T) {
+	tests := []struct {
+		hash string
+		want string
+	}{
+		{hexHash, hexHash},
+		{" 0123456789ABCDEF0123456789ABCDEF01234567 ", hexHash},
+		{base32Hash, hexHash},
+		// The hex hash hex-encoded again
+		{"30313233343536373839616263646566303132333435363738396162636465663031323334353637", hexHash},
+		{"abc", ""},
+		{"1111111111111111111111111111111111111!!!", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeInfoHash(tt.hash); got != tt.want {
+			t.Errorf("normalizeInfoHash(%q) = %q, want %q", tt.hash, got, tt.want)
+		}
+	}
+}
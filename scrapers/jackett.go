@@ -3,19 +3,24 @@ package scrapers
 import (
 	"context"
 	"crypto/sha256"
-	"encoding/json"
+	"encoding/gob"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
-	"net/url"
+	"sort"
 	"stremfy/types"
+	"stremfy/utils"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 const (
 	IndexerTimeout = 30 * time.Second
+	// DefaultMaxResults bounds how many results a single Scrape processes
+	DefaultMaxResults = 300
 )
 
 // JackettResult represents a result from Jackett API
@@ -29,6 +34,17 @@ type JackettResult struct {
 	Tracker   string `json:"Tracker"`
 	Details   string `json:"Details"`
 	Guid      string `json:"Guid"`
+	Category  []int  `json:"Category"`
+
+	// Instance names the Jackett instance the result came from when several are configured
+	Instance string `json:"-"`
+}
+
+// Register the cached scraper types, so the persisted cache decodes them
+func init() {
+	gob.Register(JackettResult{})
+	gob.Register([]JackettResult{})
+	gob.Register(CachedHash{})
 }
 
 // JackettResponse represents the API response
@@ -38,12 +54,113 @@ type JackettResponse struct {
 
 // JackettScraper handles scraping from Jackett
 type JackettScraper struct {
-	manager   ScraperManager
-	client    *http.Client
-	url       string
-	apiKey    string
-	cache     types.Cache
-	searchTTL time.Duration
+	manager    ScraperManager
+	client     *http.Client
+	instances  []*jackettInstance
+	cache      types.Cache
+	searchTTL  time.Duration
+	sizeRanges map[string]SizeRange
+	blocklist  *Blocklist
+	maxResults int
+	allowAdult bool
+	packWords  []string
+
+	unpaddedQueries bool
+	stripNoise      bool
+	emptyRetryDelay time.Duration
+}
+
+// JackettInstance is a Jackett, Prowlarr or Torznab server searched by the scraper
+type JackettInstance struct {
+	// Name labels the results of the instance, e.g. "private"
+	Name   string
+	URL    string
+	APIKey string
+	// API is the search API of the server: IndexerJackett (default), IndexerProwlarr or IndexerTorznab
+	API string
+}
+
+type jackettInstance struct {
+	JackettInstance
+
+	// unavailable is set while the last request to the instance failed
+	unavailable atomic.Bool
+}
+
+// Available reports whether the last request to any Jackett instance succeeded
+func (j *JackettScraper) Available() bool {
+	for _, inst := range j.instances {
+		if !inst.unavailable.Load() {
+			return true
+		}
+	}
+	return false
+}
+
+// setAvailable records the outcome of a request to a Jackett instance, logging state changes
+func (inst *jackettInstance) setAvailable(available bool) {
+	if inst.unavailable.Swap(!available) == available {
+		if available {
+			log.Printf("✅ Jackett %s is reachable again", inst.Name)
+		} else {
+			log.Printf("❌ Jackett %s is unavailable", inst.Name)
+		}
+	}
+}
+
+// JackettConfig holds configuration for the Jackett scraper
+type JackettConfig struct {
+	Manager   ScraperManager
+	URL       string
+	APIKey    string
+	Cache     types.Cache
+	SearchTTL time.Duration
+	// SizeRanges maps a quality label to the plausible size range of a movie with that quality
+	SizeRanges map[string]SizeRange
+	// BlockedKeywords drops results whose title contains any of these words (case-insensitive)
+	BlockedKeywords []string
+	// BlockedGroups drops results from these release groups (case-insensitive)
+	BlockedGroups []string
+	// MaxResults caps the results processed per Scrape after dedup and seeder sort
+	MaxResults int
+	// AllowAdult keeps results categorised or titled as adult content
+	AllowAdult bool
+	// PackWords are searched after a series title to find complete-series packs,
+	// e.g. "complete" (default SeriesPackWords(DefaultLocale))
+	PackWords []string
+	// UnpaddedQueries also searches series as "S1", "1x05" and "1x5" besides "S01"
+	UnpaddedQueries bool
+	// API is the search API of URL: IndexerJackett (default), IndexerProwlarr or IndexerTorznab
+	API string
+	// Instances are more servers searched alongside URL, e.g. one for private trackers
+	Instances []JackettInstance
+	// StripReleaseNoise ignores quality, codec, source, year and group tags when matching titles
+	StripReleaseNoise bool
+	// Timeout bounds each Jackett request (default IndexerTimeout)
+	Timeout time.Duration
+	// EmptyRetryDelay retries a Scrape that found nothing once after this delay (0 disables)
+	EmptyRetryDelay time.Duration
+}
+
+// DefaultLocale is the locale of the series pack queries when none is configured
+const DefaultLocale = "en"
+
+// seriesPackWords are the words releases use for complete series packs, by locale
+var seriesPackWords = map[string][]string{
+	"en": {"complete", "pack"},
+	"pt": {"completa", "completo", "pack"},
+	"es": {"completa", "pack"},
+	"fr": {"integrale", "complet", "pack"},
+}
+
+// SeriesPackWords returns the pack query words of a locale such as "pt" or "pt-BR",
+// falling back to DefaultLocale
+func SeriesPackWords(locale string) []string {
+	language, _, _ := strings.Cut(strings.ToLower(locale), "-")
+	if words, ok := seriesPackWords[language]; ok {
+		return words
+	}
+	return seriesPackWords[DefaultLocale]
 }
 
 // TorrentManager interface
@@ -52,23 +169,58 @@ type TorrentManager interface {
 	DownloadTorrent(ctx context.Context, url string) (content []byte, magnetHash string, magnetURL string, error error)
 	ExtractTorrentMetadata(content []byte) (*TorrentMetadata, error)
 	ExtractTrackersFromMagnet(magnetURL string) []string
-	GetCachedTorrentFiles(hash string) ([]TorrentFile, bool, error)
+	GetCachedTorrentFiles(ctx context.Context, hash string) ([]TorrentFile, bool, error)
 }
 
 // NewJackettScraper creates a new Jackett scraper
-func NewJackettScraper(manager ScraperManager, url, apiKey string, cache types.Cache, searchTTL time.Duration) *JackettScraper {
+func NewJackettScraper(config JackettConfig) *JackettScraper {
+	if config.SizeRanges == nil {
+		config.SizeRanges = DefaultSizeRanges
+	}
+	if config.MaxResults <= 0 {
+		config.MaxResults = DefaultMaxResults
+	}
+	if len(config.PackWords) == 0 {
+		config.PackWords = SeriesPackWords(DefaultLocale)
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = IndexerTimeout
+	}
+
 	return &JackettScraper{
-		manager: manager,
+		manager: config.Manager,
 		client: &http.Client{
-			Timeout: IndexerTimeout,
+			Timeout: config.Timeout,
 		},
-		url:       url,
-		apiKey:    apiKey,
-		cache:     cache,
-		searchTTL: searchTTL,
+		instances:  newJackettInstances(config),
+		cache:      config.Cache,
+		searchTTL:  config.SearchTTL,
+		sizeRanges: config.SizeRanges,
+		blocklist:  NewBlocklist(config.BlockedKeywords, config.BlockedGroups),
+		maxResults: config.MaxResults,
+		allowAdult: config.AllowAdult,
+		packWords:  config.PackWords,
+
+		unpaddedQueries: config.UnpaddedQueries,
+		stripNoise:      config.StripReleaseNoise,
+		emptyRetryDelay: config.EmptyRetryDelay,
 	}
 }
 
+// newJackettInstances returns the main instance followed by the extra ones
+func newJackettInstances(config JackettConfig) []*jackettInstance {
+	api := config.API
+	if api == "" {
+		api = IndexerJackett
+	}
+	main := JackettInstance{Name: api, URL: config.URL, APIKey: config.APIKey, API: api}
+	instances := []*jackettInstance{{JackettInstance: main}}
+	for _, extra := range config.Instances {
+		instances = append(instances, &jackettInstance{JackettInstance: extra})
+	}
+	return instances
+}
+
 // processTorrent processes a single torrent result
 func (j *JackettScraper) processTorrent(
 	ctx context.Context,
@@ -80,7 +232,6 @@ func (j *JackettScraper) processTorrent(
 
 	// Get the info hash first
 	var infoHash string
-	var sources []string
 
 	// Step 1: Try to get InfoHash from Jackett result
 	if result.InfoHash != "" {
@@ -89,18 +240,21 @@ func (j *JackettScraper) processTorrent(
 		if infoHash != "" {
 			log.Printf("📌 Using InfoHash from Jackett: %s", infoHash)
 
-			// Extract trackers from MagnetUri if available
-			if result.MagnetUri != "" {
-				sources = torrentMgr.ExtractTrackersFromMagnet(result.MagnetUri)
-			}
+			// Early return - we have everything we need, buildTorrentResults adds the magnet trackers
+			return j.buildTorrentResults(result, infoHash, nil, torrentMgr, mediaID, season), nil
+		}
+	}
 
-			// Early return - we have everything we need
-			return j.buildTorrentResults(result, infoHash, sources, torrentMgr, mediaID, season), nil
+	// Step 1b: Take the hash straight from the magnet, no download needed
+	if result.MagnetUri != "" {
+		if magnetHash := HashFromMagnet(result.MagnetUri); magnetHash != "" {
+			log.Printf("🧲 Using InfoHash from magnet: %s", magnetHash)
+			return j.buildTorrentResults(result, magnetHash, nil, torrentMgr, mediaID, season), nil
 		}
 	}
 
 	// Step 2: Check cache for previously downloaded hash
-	if result.Link != "" && j.cache != nil {
+	if result.Link != "" && j.cache != nil && !types.SkipCache(ctx) {
 		if cachedHash, cachedSources := j.getCachedHash(result.Link); cachedHash != "" {
 			log.Printf("📦 Cache hit for hash: %s", cachedHash)
 			return j.buildTorrentResults(result, cachedHash, cachedSources, torrentMgr, mediaID, season), nil
@@ -119,33 +273,33 @@ func (j *JackettScraper) processTorrent(
 	return nil, nil
 }
 
-// generateCacheKey generates a cache key for a search query
-func (j *JackettScraper) generateCacheKey(query string) string {
+// generateCacheKey generates a cache key for a search query of an instance
+func (j *JackettScraper) generateCacheKey(inst *jackettInstance, query string) string {
+	// The main instance keeps the keys it had before extra instances existed
+	if inst != j.instances[0] {
+		query = inst.URL + "|" + query
+	}
 	hash := sha256.Sum256([]byte(query))
 	return fmt.Sprintf("jackett_search_%x", hash)
 }
 
-// fetchJackettResults fetches results from Jackett for a given query
-func (j *JackettScraper) fetchJackettResults(ctx context.Context, query string) ([]JackettResult, error) {
+// fetchJackettResults fetches results from a Jackett instance for a given query
+func (j *JackettScraper) fetchJackettResults(ctx context.Context, inst *jackettInstance, query string) ([]JackettResult, error) {
 	// Check cache first if cache is available
-	if j.cache != nil {
-		cacheKey := j.generateCacheKey(query)
-		if cached, found := j.cache.Get(cacheKey); found {
-			if results, ok := cached.([]JackettResult); ok {
-				fmt.Printf("📦 Cache hit for Jackett search: %s\n", query)
-				return results, nil
-			}
+	if j.cache != nil && !types.SkipCache(ctx) {
+		cacheKey := j.generateCacheKey(inst, query)
+		if results, found := types.CacheGet[[]JackettResult](j.cache, cacheKey); found {
+			fmt.Printf("📦 Cache hit for Jackett search: %s\n", query)
+			return j.tagInstance(results, inst), nil
 		}
 	}
 
-	// Build URL with 'all' indexer
-	params := url.Values{}
-	params.Set("apikey", j.apiKey)
-	params.Set("Query", query)
-
-	apiURL := fmt.Sprintf("%s/api/v2.0/indexers/all/results?%s", j.url, params.Encode())
+	apiURL, err := searchURL(inst.JackettInstance, query)
+	if err != nil {
+		return nil, err
+	}
 
-	fmt.Printf("🔍 Jackett search: %s\n", query)
+	fmt.Printf("🔍 Jackett search (%s): %s\n", inst.Name, query)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
 	if err != nil {
@@ -154,68 +308,165 @@ func (j *JackettScraper) fetchJackettResults(ctx context.Context, query string)
 
 	resp, err := j.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("request failed: %w", err)
+		}
+		inst.setAvailable(false)
+		return nil, fmt.Errorf("%w: request failed: %w", ErrIndexerUnavailable, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		inst.setAvailable(false)
+		return nil, fmt.Errorf("%w: unexpected status code: %d", ErrIndexerUnavailable, resp.StatusCode)
 	}
+	inst.setAvailable(true)
 
-	var jackettResp JackettResponse
-	if err := json.NewDecoder(resp.Body).Decode(&jackettResp); err != nil {
+	results, err := decodeResults(inst.API, resp.Body)
+	if errors.Is(err, ErrIndexerUnavailable) {
+		inst.setAvailable(false)
+		return nil, err
+	}
+	if err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	fmt.Printf("✅ Jackett returned %d results for query: %s\n", len(jackettResp.Results), query)
+	fmt.Printf("✅ %s returned %d results for query: %s\n", inst.Name, len(results), query)
 
 	// Cache the results if cache is available
 	if j.cache != nil && j.searchTTL > 0 {
-		cacheKey := j.generateCacheKey(query)
-		j.cache.Set(cacheKey, jackettResp.Results, j.searchTTL)
+		cacheKey := j.generateCacheKey(inst, query)
+		j.cache.Set(cacheKey, results, j.searchTTL)
 	}
 
-	return jackettResp.Results, nil
+	return j.tagInstance(results, inst), nil
 }
 
-// Scrape performs the scraping operation
-func (j *JackettScraper) Scrape(ctx context.Context, request types.ScrapeRequest, torrentMgr TorrentManager) ([]types.ScrapeResult, error) {
+// tagInstance names the instance of the results when several instances are searched
+func (j *JackettScraper) tagInstance(results []JackettResult, inst *jackettInstance) []JackettResult {
+	if len(j.instances) < 2 {
+		return results
+	}
+	tagged := make([]JackettResult, len(results))
+	for i, result := range results {
+		result.Instance = inst.Name
+		tagged[i] = result
+	}
+	return tagged
+}
+
+// scrapeChanSize is the buffer of the Scrape result channels; producers select on
+// ctx.Done when sending, so a small buffer never leaves a goroutine blocked
+const scrapeChanSize = 8
+
+// buildQueries returns the Jackett queries searched for a request, for each of its titles
+func (j *JackettScraper) buildQueries(request types.ScrapeRequest) []string {
+	var queries []string
+	for _, title := range request.Titles() {
+		queries = append(queries, j.titleQueries(request, title)...)
+	}
+	return queries
+}
+
+// titleQueries returns the Jackett queries searched for one title of a request
+func (j *JackettScraper) titleQueries(request types.ScrapeRequest, title string) []string {
 	var queries []string
 	if request.MediaType == "movie" {
-		queries = append(queries, request.Title)
+		queries = append(queries, title)
+		if request.Year != "" {
+			queries = append(queries, fmt.Sprintf("%s %s", title, request.Year))
+		}
+	} else if request.MediaType == "series" && request.Episode != nil && request.Season == 0 {
+		// Season 0 holds specials/OVAs, which are rarely tagged S00
+		queries = append(queries, fmt.Sprintf("%s s00", title))
+		queries = append(queries, fmt.Sprintf("%s special", title))
+		queries = append(queries, fmt.Sprintf("%s ova", title))
 	} else if request.MediaType == "series" && request.Episode != nil {
-		queries = append(queries, fmt.Sprintf("%s s%02d", request.Title, request.Season))
-		queries = append(queries, fmt.Sprintf("%s complet", request.Title))
-		queries = append(queries, fmt.Sprintf("%s pack", request.Title))
+		queries = append(queries, fmt.Sprintf("%s s%02d", title, request.Season))
+		if j.unpaddedQueries {
+			// Some indexers only match unpadded numbering, e.g. "S1", "1x05" or "1x5"
+			if request.Season < 10 {
+				queries = append(queries, fmt.Sprintf("%s s%d", title, request.Season))
+			}
+			queries = append(queries, fmt.Sprintf("%s %dx%02d", title, request.Season, *request.Episode))
+			if *request.Episode < 10 {
+				queries = append(queries, fmt.Sprintf("%s %dx%d", title, request.Season, *request.Episode))
+			}
+		}
+		for _, word := range j.packWords {
+			queries = append(queries, fmt.Sprintf("%s %s", title, word))
+		}
 		if request.Season != 1 {
-			queries = append(queries, fmt.Sprintf("%s s01-", request.Title))
+			queries = append(queries, fmt.Sprintf("%s s01-", title))
+		}
+		if request.AbsoluteEpisode > 0 {
+			// Anime releases number episodes across seasons, e.g. "Title - 29"
+			queries = append(queries, fmt.Sprintf("%s %02d", title, request.AbsoluteEpisode))
 		}
 	}
+	return queries
+}
+
+// Scrape performs the scraping operation, retrying once after EmptyRetryDelay when nothing is found
+func (j *JackettScraper) Scrape(ctx context.Context, request types.ScrapeRequest, torrentMgr TorrentManager) ([]types.ScrapeResult, error) {
+	start := time.Now()
+	results, err := j.scrape(ctx, request, torrentMgr)
+	if err != nil || len(results) > 0 || j.emptyRetryDelay <= 0 {
+		return results, err
+	}
+
+	// Indexers sometimes return nothing for a moment. Only retry when the deadline leaves room
+	// for the delay and another attempt as long as the first one.
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < j.emptyRetryDelay+time.Since(start) {
+		return results, nil
+	}
+
+	log.Printf("🔁 No results for '%s', retrying in %v", request.Title, j.emptyRetryDelay)
+	select {
+	case <-time.After(j.emptyRetryDelay):
+	case <-ctx.Done():
+		return results, nil
+	}
+
+	// The empty responses of the first attempt were cached
+	return j.scrape(types.WithSkipCache(ctx), request, torrentMgr)
+}
+
+// scrape runs every query against every instance once and processes the matching results
+func (j *JackettScraper) scrape(ctx context.Context, request types.ScrapeRequest, torrentMgr TorrentManager) ([]types.ScrapeResult, error) {
+	queries := j.buildQueries(request)
 
 	// Use a wait group to fetch all queries concurrently
 	var wg sync.WaitGroup
-	resultsChan := make(chan []JackettResult, len(queries))
-	errorsChan := make(chan error, len(queries))
-
-	// Fetch results for all queries concurrently
-	for _, query := range queries {
-		wg.Add(1)
-		go func(q string) {
-			defer wg.Done()
-			results, err := j.fetchJackettResults(ctx, q)
-			if err != nil {
-				errorsChan <- err
-				return
-			}
-			resultsChan <- results
-		}(query)
+	var unavailable atomic.Int32
+	resultsChan := make(chan []JackettResult, scrapeChanSize)
+
+	// Fetch results for all queries from every instance concurrently
+	for _, inst := range j.instances {
+		for _, query := range queries {
+			wg.Add(1)
+			go func(inst *jackettInstance, q string) {
+				defer wg.Done()
+				results, err := j.fetchJackettResults(ctx, inst, q)
+				if err != nil {
+					log.Printf("⚠️ Error fetching Jackett %s results for '%s': %v", inst.Name, q, err)
+					if errors.Is(err, ErrIndexerUnavailable) {
+						unavailable.Add(1)
+					}
+					return
+				}
+				select {
+				case resultsChan <- results:
+				case <-ctx.Done():
+				}
+			}(inst, query)
+		}
 	}
 
 	// Wait for all fetches to complete
 	go func() {
 		wg.Wait()
 		close(resultsChan)
-		close(errorsChan)
 	}()
 
 	// Collect all results
@@ -223,15 +474,39 @@ func (j *JackettScraper) Scrape(ctx context.Context, request types.ScrapeRequest
 	seen := make(map[string]bool)
 
 	matcher := NewTitleMatcher(85)
+	matcher.stripNoise = j.stripNoise
 	for results := range resultsChan {
 		for _, result := range results {
 			// Deduplicate by Details field
 			if !seen[result.Details] {
 				seen[result.Details] = true
 
-				// Filter by title match
-				if !matcher.Matches(request.Title, result.Title) {
-					log.Printf("🚫 Title mismatch: expected '%s', got '%s'", request.Title, result.Title)
+				// Season/series packs only, e.g. for storage-conscious prefetching
+				if request.PacksOnly && !IsPack(result.Title) {
+					continue
+				}
+
+				// Filter by title match, against any title of the request
+				if !matcher.MatchesAny(request.Titles(), result.Title) {
+					log.Printf("🚫 Title mismatch: expected '%s', got '%s'", strings.Join(request.Titles(), "' or '"), result.Title)
+					continue
+				}
+
+				// Filter out adult content
+				if !j.allowAdult && isAdultResult(result) {
+					log.Printf("🔞 Filtered adult result: %s", result.Title)
+					continue
+				}
+
+				// Filter out blocked keywords and release groups
+				if reason := j.blocklist.Blocked(result.Title); reason != "" {
+					log.Printf("🚫 Blocked %s: %s", reason, result.Title)
+					continue
+				}
+
+				// Filter out fakes whose size doesn't fit the claimed quality
+				if !IsSizeSaneForQuality(result.Title, result.Size, request.MediaType == "series", j.sizeRanges) {
+					log.Printf("🚫 Implausible size %d for quality: %s", result.Size, result.Title)
 					continue
 				}
 
@@ -247,14 +522,23 @@ func (j *JackettScraper) Scrape(ctx context.Context, request types.ScrapeRequest
 		}
 	}
 
-	// Log any errors
-	for err := range errorsChan {
-		fmt.Printf("Warning: Error fetching Jackett results: %v\n", err)
+	// Every query failing because Jackett is down is not the same as finding nothing
+	if len(queries) > 0 && int(unavailable.Load()) == len(queries)*len(j.instances) {
+		return nil, fmt.Errorf("jackett: %w", ErrIndexerUnavailable)
+	}
+
+	// Keep only the best-seeded results to bound the processing fan-out
+	sort.SliceStable(allResults, func(a, b int) bool {
+		return seedersOf(allResults[a]) > seedersOf(allResults[b])
+	})
+	if len(allResults) > j.maxResults {
+		log.Printf("✂️ Truncating %d results to %d", len(allResults), j.maxResults)
+		allResults = allResults[:j.maxResults]
 	}
 
 	// Process all torrents concurrently
 	var processingWg sync.WaitGroup
-	torrentsChan := make(chan []types.ScrapeResult, len(allResults))
+	torrentsChan := make(chan []types.ScrapeResult, scrapeChanSize)
 
 	for _, result := range allResults {
 		processingWg.Add(1)
@@ -266,7 +550,11 @@ func (j *JackettScraper) Scrape(ctx context.Context, request types.ScrapeRequest
 				return
 			}
 			if len(torrents) > 0 {
-				torrentsChan <- torrents
+				// The collector stops reading once ctx is done, so never block on the send
+				select {
+				case torrentsChan <- torrents:
+				case <-ctx.Done():
+				}
 			}
 		}(result)
 	}
@@ -277,29 +565,53 @@ func (j *JackettScraper) Scrape(ctx context.Context, request types.ScrapeRequest
 		close(torrentsChan)
 	}()
 
-	// Collect all processed torrents
+	// Collect all processed torrents, returning what we have once ctx is done.
+	// The same torrent can be listed under several titles or indexers, keep it once.
 	var finalTorrents []types.ScrapeResult
-	for torrents := range torrentsChan {
-		for _, torrent := range torrents {
-			if torrent.InfoHash != "" {
-				finalTorrents = append(finalTorrents, torrent)
+	seenHashes := make(map[string]bool)
+	for {
+		select {
+		case torrents, ok := <-torrentsChan:
+			if !ok {
+				return finalTorrents, nil
+			}
+			for _, torrent := range torrents {
+				if torrent.InfoHash != "" && !seenHashes[torrent.InfoHash] {
+					seenHashes[torrent.InfoHash] = true
+					finalTorrents = append(finalTorrents, torrent)
+				}
 			}
+		case <-ctx.Done():
+			log.Printf("⏱ Scrape cut short with %d torrents processed: %v", len(finalTorrents), ctx.Err())
+			return finalTorrents, ctx.Err()
 		}
 	}
+}
+
+// seedersOf returns the seeders of a result, or 0 when unknown
+func seedersOf(result JackettResult) int {
+	if result.Seeders == nil {
+		return 0
+	}
+	return *result.Seeders
+}
 
-	return finalTorrents, nil
+// CachedHash is the info hash and trackers extracted from a torrent link, cached permanently
+type CachedHash struct {
+	Hash    string
+	Sources []string
 }
 
 // getCachedHash retrieves hash and sources from cache
 func (j *JackettScraper) getCachedHash(link string) (hash string, sources []string) {
 	cacheKey := fmt.Sprintf("hash_%s", link)
-	cached, found := j.cache.Get(cacheKey)
-	if !found {
-		return "", nil
+	if cached, found := types.CacheGet[CachedHash](j.cache, cacheKey); found {
+		return cached.Hash, cached.Sources
 	}
 
-	hashData, ok := cached.(map[string]interface{})
-	if !ok {
+	// Entries cached before CachedHash
+	hashData, found := types.CacheGet[map[string]interface{}](j.cache, cacheKey)
+	if !found {
 		return "", nil
 	}
 
@@ -334,17 +646,18 @@ func (j *JackettScraper) downloadAndExtractHash(
 	// Fallback to magnet link
 	if hash == "" && magnetHash != "" {
 		hash = strings.ToLower(magnetHash)
-		sources = torrentMgr.ExtractTrackersFromMagnet(magnetURL)
 		log.Printf("🧲 Extracted hash from magnet: %s", hash)
 	}
 
+	// Use the trackers of both the announce list and the magnet
+	if magnetURL != "" {
+		sources = utils.MergeTrackers(sources, torrentMgr.ExtractTrackersFromMagnet(magnetURL))
+	}
+
 	// Cache the result if we got a hash
 	if hash != "" && j.cache != nil {
 		cacheKey := fmt.Sprintf("hash_%s", link)
-		j.cache.SetPermanent(cacheKey, map[string]interface{}{
-			"hash":    hash,
-			"sources": sources,
-		})
+		j.cache.SetPermanent(cacheKey, CachedHash{Hash: hash, Sources: sources})
 		log.Printf("💾 Cached hash for future use")
 	}
 
@@ -360,6 +673,12 @@ func (j *JackettScraper) buildTorrentResults(
 	mediaID string,
 	season int,
 ) []types.ScrapeResult {
+	// Merge the magnet's trackers into the announce list, deduplicated case-insensitively.
+	// The default public trackers are merged in when the stream is built.
+	if result.MagnetUri != "" {
+		sources = utils.MergeTrackers(sources, torrentMgr.ExtractTrackersFromMagnet(result.MagnetUri))
+	}
+
 	torrent := types.ScrapeResult{
 		Title:     result.Title,
 		InfoHash:  infoHash,
@@ -369,6 +688,9 @@ func (j *JackettScraper) buildTorrentResults(
 		Tracker:   result.Tracker,
 		Sources:   sources,
 	}
+	if result.Instance != "" {
+		torrent.Tracker = fmt.Sprintf("%s (%s)", result.Tracker, result.Instance)
+	}
 
 	// Add to torrent queue if we have a magnet URI
 	if result.MagnetUri != "" {
@@ -0,0 +1,355 @@
+package scrapers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sort"
+	"stremfy/types"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeJackett serves the results of each query in the Jackett JSON format and counts the searches
+type fakeJackett struct {
+	*httptest.Server
+	searches atomic.Int32
+}
+
+func newFakeJackett(t *testing.T, results func(query string) []JackettResult) *fakeJackett {
+	t.Helper()
+	fake := &fakeJackett{}
+	fake.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fake.searches.Add(1)
+		json.NewEncoder(w).Encode(JackettResponse{Results: results(r.URL.Query().Get("Query"))})
+	}))
+	t.Cleanup(fake.Close)
+	return fake
+}
+
+func seeders(n int) *int {
+	return &n
+}
+
+func hashes(torrents []types.ScrapeResult) []string {
+	var list []string
+	for _, torrent := range torrents {
+		list = append(list, torrent.InfoHash)
+	}
+	sort.Strings(list)
+	return list
+}
+
+func titles(torrents []types.ScrapeResult) []string {
+	var list []string
+	for _, torrent := range torrents {
+		list = append(list, torrent.Title)
+	}
+	sort.Strings(list)
+	return list
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestScrapeDeduplicates(t *testing.T) {
+	const hashA = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	const hashB = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+
+	jackett := newFakeJackett(t, func(query string) []JackettResult {
+		return []JackettResult{
+			// The same listing returned twice
+			{Title: "Inception 2010", Details: "https://t1/1", InfoHash: hashA, Seeders: seeders(10)},
+			{Title: "Inception 2010", Details: "https://t1/1", InfoHash: hashA, Seeders: seeders(10)},
+			// The same torrent listed by another tracker, its hash only known from the torrent file
+			{Title: "Inception.2010", Details: "https://t2/9", Link: "https://t2/9.torrent", Seeders: seeders(5)},
+			{Title: "Inception 2010 REPACK", Details: "https://t3/4", InfoHash: hashB, Seeders: seeders(3)},
+		}
+	})
+	manager := newFakeTorrentManager()
+	manager.addLink("https://t2/9.torrent", fakeTorrent{InfoHash: hashA})
+
+	scraper := NewJackettScraper(JackettConfig{URL: jackett.URL, APIKey: "key"})
+	torrents, err := scraper.Scrape(context.Background(), types.ScrapeRequest{Title: "Inception", MediaType: "movie", MediaOnlyID: "tt1375666"}, manager)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := hashes(torrents), []string{hashA, hashB}; !equalStrings(got, want) {
+		t.Errorf("hashes = %v, want %v", got, want)
+	}
+	if n := manager.downloadCount("https://t2/9.torrent"); n != 1 {
+		t.Errorf("torrent file downloaded %d times, want 1", n)
+	}
+}
+
+func TestScrapeMergesTitleVariants(t *testing.T) {
+	const hash = "cccccccccccccccccccccccccccccccccccccccc"
+
+	jackett := newFakeJackett(t, func(query string) []JackettResult {
+		// Both titles find the same torrent, listed by two trackers, which only names the original title
+		if query == "A Viagem de Chihiro" {
+			return []JackettResult{
+				{Title: "Spirited.Away.2001.1080p.BluRay", Details: "https://t1/7", InfoHash: hash, Seeders: seeders(8)},
+				{Title: "Unrelated Movie 2001 1080p", Details: "https://t1/8", InfoHash: "dddddddddddddddddddddddddddddddddddddddd"},
+			}
+		}
+		return []JackettResult{
+			{Title: "Spirited Away 2001 1080p BluRay", Details: "https://t2/3", InfoHash: strings.ToUpper(hash), Seeders: seeders(20)},
+		}
+	})
+
+	scraper := NewJackettScraper(JackettConfig{URL: jackett.URL, APIKey: "key"})
+	torrents, err := scraper.Scrape(context.Background(), types.ScrapeRequest{
+		Title: "A Viagem de Chihiro", AltTitles: []string{"Spirited Away"}, MediaType: "movie", MediaOnlyID: "tt0245429",
+	}, newFakeTorrentManager())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(torrents) != 1 || !strings.EqualFold(torrents[0].InfoHash, hash) {
+		t.Errorf("torrents = %+v, want only %s once", torrents, hash)
+	}
+	if n := jackett.searches.Load(); n != 2 {
+		t.Errorf("searched %d times, want once per title", n)
+	}
+}
+
+func TestScrapeSeasonPacks(t *testing.T) {
+	results := []JackettResult{
+		{Title: "Severance S02 1080p WEB-DL", Size: 20 << 30},
+		{Title: "Severance S01-S02 1080p WEB-DL", Size: 40 << 30},
+		{Title: "Severance S02E03 1080p WEB-DL", Size: 2 << 30},
+		{Title: "Severance S02E02-E05 1080p WEB-DL", Size: 8 << 30},
+		{Title: "Severance S02E01-E02 1080p WEB-DL", Size: 4 << 30},
+		{Title: "Severance S02E06-E09 1080p WEB-DL", Size: 8 << 30},
+	}
+	for i := range results {
+		results[i].Details = fmt.Sprintf("https://t/%d", i)
+		results[i].InfoHash = fmt.Sprintf("%040d", i)
+	}
+	jackett := newFakeJackett(t, func(query string) []JackettResult { return results })
+
+	episode := 3
+	scraper := NewJackettScraper(JackettConfig{URL: jackett.URL, APIKey: "key"})
+	torrents, err := scraper.Scrape(context.Background(), types.ScrapeRequest{
+		Title: "Severance", MediaType: "series", MediaOnlyID: "tt11280740", Season: 2, Episode: &episode,
+	}, newFakeTorrentManager())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"Severance S01-S02 1080p WEB-DL",
+		"Severance S02 1080p WEB-DL",
+		"Severance S02E02-E05 1080p WEB-DL",
+		"Severance S02E03 1080p WEB-DL",
+	}
+	if got := titles(torrents); !equalStrings(got, want) {
+		t.Errorf("titles = %v, want %v", got, want)
+	}
+}
+
+func TestScrapeProcessesConcurrently(t *testing.T) {
+	const count = 40
+
+	manager := newFakeTorrentManager()
+	manager.delay = 20 * time.Millisecond
+	var results []JackettResult
+	for i := range count {
+		link := fmt.Sprintf("https://t/%d.torrent", i)
+		manager.addLink(link, fakeTorrent{InfoHash: fmt.Sprintf("%040d", i)})
+		results = append(results, JackettResult{Title: "Inception 2010", Details: link, Link: link, Seeders: seeders(i)})
+	}
+	jackett := newFakeJackett(t, func(query string) []JackettResult { return results })
+
+	scraper := NewJackettScraper(JackettConfig{URL: jackett.URL, APIKey: "key"})
+	start := time.Now()
+	torrents, err := scraper.Scrape(context.Background(), types.ScrapeRequest{Title: "Inception", MediaType: "movie", MediaOnlyID: "tt1375666"}, manager)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(torrents) != count {
+		t.Errorf("got %d torrents, want %d", len(torrents), count)
+	}
+	if peak := manager.peakDownloads(); peak < 2 {
+		t.Errorf("at most %d download in flight, want concurrent downloads", peak)
+	}
+	if elapsed := time.Since(start); elapsed > count*manager.delay/2 {
+		t.Errorf("took %v, downloads look sequential", elapsed)
+	}
+}
+
+func TestScrapeRetriesOnlyEmptySearches(t *testing.T) {
+	const hash = "0123456789abcdef0123456789abcdef01234567"
+	found := []JackettResult{{Title: "Inception 2010", Details: "https://t/1", InfoHash: hash, Seeders: seeders(5)}}
+	request := types.ScrapeRequest{Title: "Inception", MediaType: "movie", MediaOnlyID: "tt1375666"}
+	const delay = 50 * time.Millisecond
+
+	t.Run("empty then results", func(t *testing.T) {
+		var calls atomic.Int32
+		jackett := newFakeJackett(t, func(query string) []JackettResult {
+			if calls.Add(1) == 1 {
+				return nil
+			}
+			return found
+		})
+		scraper := NewJackettScraper(JackettConfig{URL: jackett.URL, APIKey: "key", EmptyRetryDelay: delay})
+
+		torrents, err := scraper.Scrape(context.Background(), request, newFakeTorrentManager())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(torrents) != 1 || jackett.searches.Load() != 2 {
+			t.Errorf("got %d torrents after %d searches, want 1 after a retry", len(torrents), jackett.searches.Load())
+		}
+	})
+
+	t.Run("results first", func(t *testing.T) {
+		jackett := newFakeJackett(t, func(query string) []JackettResult { return found })
+		scraper := NewJackettScraper(JackettConfig{URL: jackett.URL, APIKey: "key", EmptyRetryDelay: delay})
+
+		torrents, err := scraper.Scrape(context.Background(), request, newFakeTorrentManager())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(torrents) != 1 || jackett.searches.Load() != 1 {
+			t.Errorf("got %d torrents after %d searches, want 1 without a retry", len(torrents), jackett.searches.Load())
+		}
+	})
+
+	t.Run("deadline too tight", func(t *testing.T) {
+		jackett := newFakeJackett(t, func(query string) []JackettResult { return nil })
+		scraper := NewJackettScraper(JackettConfig{URL: jackett.URL, APIKey: "key", EmptyRetryDelay: delay})
+
+		ctx, cancel := context.WithTimeout(context.Background(), delay/2)
+		defer cancel()
+		start := time.Now()
+		torrents, err := scraper.Scrape(ctx, request, newFakeTorrentManager())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(torrents) != 0 || jackett.searches.Load() != 1 {
+			t.Errorf("got %d torrents after %d searches, want none without a retry", len(torrents), jackett.searches.Load())
+		}
+		if elapsed := time.Since(start); elapsed >= delay/2 {
+			t.Errorf("took %v, the retry should be skipped without waiting", elapsed)
+		}
+	})
+}
+
+func TestScrapeCanceledLeaksNoGoroutines(t *testing.T) {
+	// Downloads finishing after the deadline send to a collector that stopped reading
+	manager := newFakeTorrentManager()
+	manager.delay = 300 * time.Millisecond
+	manager.ignoreCancel = true
+	var results []JackettResult
+	for i := range 200 {
+		link := fmt.Sprintf("https://t/%d.torrent", i)
+		manager.addLink(link, fakeTorrent{InfoHash: fmt.Sprintf("%040d", i)})
+		results = append(results, JackettResult{Title: "Inception 2010", Details: link, Link: link})
+	}
+	jackett := newFakeJackett(t, func(query string) []JackettResult { return results })
+	scraper := NewJackettScraper(JackettConfig{URL: jackett.URL, APIKey: "key"})
+	request := types.ScrapeRequest{Title: "Inception", MediaType: "movie", MediaOnlyID: "tt1375666"}
+
+	before := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := scraper.Scrape(ctx, request, manager); err == nil {
+		t.Fatal("Scrape finished before the deadline, the test needs slower downloads")
+	}
+
+	scraper.client.CloseIdleConnections()
+	deadline := time.Now().Add(3 * manager.delay)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines left running after the canceled Scrape, %d before", runtime.NumGoroutine(), before)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestBuildQueries(t *testing.T) {
+	episode := func(n int) *int { return &n }
+
+	tests := []struct {
+		name     string
+		unpadded bool
+		request  types.ScrapeRequest
+		want     []string
+	}{
+		{
+			name:    "movie",
+			request: types.ScrapeRequest{Title: "Inception", MediaType: "movie"},
+			want:    []string{"Inception"},
+		},
+		{
+			name:    "movie with year",
+			request: types.ScrapeRequest{Title: "Dune", Year: "1984", MediaType: "movie"},
+			want:    []string{"Dune", "Dune 1984"},
+		},
+		{
+			name:    "movie with alternative title",
+			request: types.ScrapeRequest{Title: "Spirited Away", AltTitles: []string{"Sen to Chihiro"}, MediaType: "movie"},
+			want:    []string{"Spirited Away", "Sen to Chihiro"},
+		},
+		{
+			name:    "first season episode",
+			request: types.ScrapeRequest{Title: "Severance", MediaType: "series", Season: 1, Episode: episode(5)},
+			want:    []string{"Severance s01", "Severance complete", "Severance pack"},
+		},
+		{
+			name:    "later season episode",
+			request: types.ScrapeRequest{Title: "Severance", MediaType: "series", Season: 2, Episode: episode(3)},
+			want:    []string{"Severance s02", "Severance complete", "Severance pack", "Severance s01-"},
+		},
+		{
+			name:     "unpadded single digits",
+			unpadded: true,
+			request:  types.ScrapeRequest{Title: "Severance", MediaType: "series", Season: 1, Episode: episode(5)},
+			want:     []string{"Severance s01", "Severance s1", "Severance 1x05", "Severance 1x5", "Severance complete", "Severance pack"},
+		},
+		{
+			name:     "unpadded double digits",
+			unpadded: true,
+			request:  types.ScrapeRequest{Title: "Lost", MediaType: "series", Season: 10, Episode: episode(12)},
+			want:     []string{"Lost s10", "Lost 10x12", "Lost complete", "Lost pack", "Lost s01-"},
+		},
+		{
+			name:    "specials",
+			request: types.ScrapeRequest{Title: "Doctor Who", MediaType: "series", Season: 0, Episode: episode(1)},
+			want:    []string{"Doctor Who s00", "Doctor Who special", "Doctor Who ova"},
+		},
+		{
+			name:    "anime absolute episode",
+			request: types.ScrapeRequest{Title: "Frieren", MediaType: "series", Season: 2, Episode: episode(1), AbsoluteEpisode: 29},
+			want:    []string{"Frieren s02", "Frieren complete", "Frieren pack", "Frieren s01-", "Frieren 29"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scraper := NewJackettScraper(JackettConfig{UnpaddedQueries: tt.unpadded})
+			if got := scraper.buildQueries(tt.request); !equalStrings(got, tt.want) {
+				t.Errorf("buildQueries() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package scrapers
 import (
 	"regexp"
 	"strconv"
+	"stremfy/utils"
 	"strings"
 	"unicode"
 )
@@ -10,6 +11,8 @@ import (
 // TitleMatcher handles title matching with multiple strategies
 type TitleMatcher struct {
 	minScore int
+	// stripNoise drops release tags (quality, codec, source, year, group) from torrent titles before scoring
+	stripNoise bool
 }
 
 func NewTitleMatcher(minScore int) *TitleMatcher {
@@ -19,11 +22,24 @@ func NewTitleMatcher(minScore int) *TitleMatcher {
 	return &TitleMatcher{minScore: minScore}
 }
 
+// MatchesAny reports whether the torrent matches any of the titles, e.g. localized and original
+func (tm *TitleMatcher) MatchesAny(searchTitles []string, torrentTitle string) bool {
+	for _, searchTitle := range searchTitles {
+		if tm.Matches(searchTitle, torrentTitle) {
+			return true
+		}
+	}
+	return false
+}
+
 // Matches checks if torrent title matches search title
 func (tm *TitleMatcher) Matches(searchTitle, torrentTitle string) bool {
 	// Strategy 1: Normalized exact/contains match (fast)
 	search := tm.normalize(searchTitle)
 	torrent := tm.normalize(torrentTitle)
+	if tm.stripNoise {
+		torrent = stripReleaseNoise(torrent, search, torrentTitle)
+	}
 
 	if search == torrent || strings.Contains(torrent, search) {
 		return true
@@ -43,6 +59,27 @@ func (tm *TitleMatcher) Matches(searchTitle, torrentTitle string) bool {
 	return false
 }
 
+var yearWordPattern = regexp.MustCompile(`^(19|20)\d{2}$`)
+
+// stripReleaseNoise removes the quality, codec and source tags, years and the release group
+// from a normalized torrent title, keeping any word that is part of the search title
+func stripReleaseNoise(torrent, search, rawTitle string) string {
+	searchWords := make(map[string]bool)
+	for _, word := range strings.Fields(search) {
+		searchWords[word] = true
+	}
+	group := strings.ToLower(utils.ExtractReleaseGroup(rawTitle))
+
+	var kept []string
+	for _, word := range strings.Fields(torrent) {
+		noise := utils.IsReleaseNoise(word) || yearWordPattern.MatchString(word) || word == group
+		if !noise || searchWords[word] {
+			kept = append(kept, word)
+		}
+	}
+	return strings.Join(kept, " ")
+}
+
 func (tm *TitleMatcher) normalize(title string) string {
 	title = strings.ToLower(title)
 
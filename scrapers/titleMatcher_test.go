@@ -0,0 +1,49 @@
+package scrapers
+
+import "testing"
+
+func TestMatchesAny(t *testing.T) {
+	tm := NewTitleMatcher(0)
+	titles := []string{"A Viagem de Chihiro", "Spirited Away"}
+
+	tests := []struct {
+		torrent string
+		want    bool
+	}{
+		{"A.Viagem.de.Chihiro.2001.1080p.Dublado", true},
+		{"Spirited.Away.2001.1080p.BluRay.x264", true},
+		{"Howls.Moving.Castle.2004.1080p.BluRay", false},
+	}
+	for _, tt := range tests {
+		if got := tm.MatchesAny(titles, tt.torrent); got != tt.want {
+			t.Errorf("MatchesAny(%q) = %v, want %v", tt.torrent, got, tt.want)
+		}
+	}
+
+	if tm.MatchesAny(nil, "Spirited.Away.2001.1080p") {
+		t.Error("MatchesAny with no titles = true, want false")
+	}
+}
+
+func TestStripReleaseNoise(t *testing.T) {
+	tm := NewTitleMatcher(0)
+
+	tests := []struct {
+		search string
+		title  string
+		want   string
+	}{
+		{"Inception", "Inception.2010.1080p.BluRay.x264-RARBG", "inception"},
+		{"The Office", "The.Office.US.S01.720p.WEB-DL.DD5.1.H264-NTb", "the office us s01 web dl dd5 1"},
+		// Words of the searched title are kept even when they are keywords
+		{"1917", "1917.2019.2160p.UHD.BluRay.x265", "1917"},
+		{"Cam", "Cam.2018.1080p.NF.WEBRip.x264", "cam nf"},
+		{"Married at First Sight", "Married.at.First.Sight.AU.S01.HDTV.x264", "married at first sight au s01"},
+	}
+	for _, tt := range tests {
+		search := tm.normalize(tt.search)
+		if got := stripReleaseNoise(tm.normalize(tt.title), search, tt.title); got != tt.want {
+			t.Errorf("stripReleaseNoise(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package scrapers
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Indexer APIs a JackettInstance can be searched through
+const (
+	IndexerJackett  = "jackett"  // Jackett's JSON /api/v2.0/indexers/all/results
+	IndexerProwlarr = "prowlarr" // Prowlarr's JSON /api/v1/search
+	IndexerTorznab  = "torznab"  // a Torznab XML feed, e.g. http://prowlarr:9696/1/api
+)
+
+// IndexerAPIs lists the supported indexer APIs
+var IndexerAPIs = []string{IndexerJackett, IndexerProwlarr, IndexerTorznab}
+
+// searchURL builds the search request URL of query for the API of the instance
+func searchURL(inst JackettInstance, query string) (string, error) {
+	params := url.Values{}
+	params.Set("apikey", inst.APIKey)
+
+	switch inst.API {
+	case IndexerProwlarr:
+		params.Set("query", query)
+		params.Set("type", "search")
+		return fmt.Sprintf("%s/api/v1/search?%s", inst.URL, params.Encode()), nil
+	case IndexerTorznab:
+		// The URL is the feed itself and may already carry params
+		feedURL, err := url.Parse(inst.URL)
+		if err != nil {
+			return "", fmt.Errorf("invalid torznab URL: %w", err)
+		}
+		feed := feedURL.Query()
+		feed.Set("apikey", inst.APIKey)
+		feed.Set("t", "search")
+		feed.Set("q", query)
+		feedURL.RawQuery = feed.Encode()
+		return feedURL.String(), nil
+	}
+
+	params.Set("Query", query)
+	return fmt.Sprintf("%s/api/v2.0/indexers/all/results?%s", inst.URL, params.Encode()), nil
+}
+
+// decodeResults parses a search response of the API into Jackett results
+func decodeResults(api string, body io.Reader) ([]JackettResult, error) {
+	switch api {
+	case IndexerProwlarr:
+		return decodeProwlarr(body)
+	case IndexerTorznab:
+		return decodeTorznab(body)
+	}
+
+	var jackettResp JackettResponse
+	if err := json.NewDecoder(body).Decode(&jackettResp); err != nil {
+		return nil, err
+	}
+	return jackettResp.Results, nil
+}
+
+// prowlarrRelease is a result of the Prowlarr search API
+type prowlarrRelease struct {
+	Title       string `json:"title"`
+	GUID        string `json:"guid"`
+	Size        int64  `json:"size"`
+	Seeders     *int   `json:"seeders"`
+	InfoHash    string `json:"infoHash"`
+	MagnetURL   string `json:"magnetUrl"`
+	DownloadURL string `json:"downloadUrl"`
+	InfoURL     string `json:"infoUrl"`
+	Indexer     string `json:"indexer"`
+	Categories  []struct {
+		ID int `json:"id"`
+	} `json:"categories"`
+}
+
+func decodeProwlarr(body io.Reader) ([]JackettResult, error) {
+	var releases []prowlarrRelease
+	if err := json.NewDecoder(body).Decode(&releases); err != nil {
+		return nil, err
+	}
+
+	results := make([]JackettResult, 0, len(releases))
+	for _, release := range releases {
+		result := JackettResult{
+			Title:     release.Title,
+			Link:      release.DownloadURL,
+			InfoHash:  release.InfoHash,
+			MagnetUri: release.MagnetURL,
+			Seeders:   release.Seeders,
+			Size:      release.Size,
+			Tracker:   release.Indexer,
+			Details:   release.InfoURL,
+			Guid:      release.GUID,
+		}
+		// Details deduplicates results, some indexers have no info page
+		if result.Details == "" {
+			result.Details = release.GUID
+		}
+		for _, category := range release.Categories {
+			result.Category = append(result.Category, category.ID)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// torznabFeed is a Torznab RSS feed, or an error document when XMLName is "error"
+type torznabFeed struct {
+	XMLName xml.Name
+	Code    string `xml:"code,attr"`
+	Message string `xml:"description,attr"`
+	Channel struct {
+		Items []torznabItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type torznabItem struct {
+	Title      string   `xml:"title"`
+	GUID       string   `xml:"guid"`
+	Link       string   `xml:"link"`
+	Comments   string   `xml:"comments"`
+	Size       int64    `xml:"size"`
+	Categories []string `xml:"category"`
+	Enclosure  struct {
+		URL    string `xml:"url,attr"`
+		Length int64  `xml:"length,attr"`
+	} `xml:"enclosure"`
+	JackettIndexer  string `xml:"jackettindexer"`
+	ProwlarrIndexer string `xml:"prowlarrindexer"`
+	// Attrs are the torznab:attr elements, e.g. seeders, infohash, magneturl and size
+	Attrs []struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:"value,attr"`
+	} `xml:"attr"`
+}
+
+// attr returns the value of a torznab:attr, "" when missing
+func (item torznabItem) attr(name string) string {
+	for _, attr := range item.Attrs {
+		if strings.EqualFold(attr.Name, name) {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+func decodeTorznab(body io.Reader) ([]JackettResult, error) {
+	var feed torznabFeed
+	if err := xml.NewDecoder(body).Decode(&feed); err != nil {
+		return nil, err
+	}
+	if feed.XMLName.Local == "error" {
+		return nil, fmt.Errorf("%w: torznab error %s: %s", ErrIndexerUnavailable, feed.Code, feed.Message)
+	}
+
+	results := make([]JackettResult, 0, len(feed.Channel.Items))
+	for _, item := range feed.Channel.Items {
+		result := JackettResult{
+			Title:     item.Title,
+			Link:      item.Enclosure.URL,
+			InfoHash:  item.attr("infohash"),
+			MagnetUri: item.attr("magneturl"),
+			Size:      item.Size,
+			Tracker:   item.JackettIndexer,
+			Details:   item.Comments,
+			Guid:      item.GUID,
+		}
+		if result.Link == "" {
+			result.Link = item.Link
+		}
+		if strings.HasPrefix(result.Link, "magnet:") {
+			if result.MagnetUri == "" {
+				result.MagnetUri = result.Link
+			}
+			result.Link = ""
+		}
+		if result.Tracker == "" {
+			result.Tracker = item.ProwlarrIndexer
+		}
+		if result.Details == "" {
+			result.Details = item.GUID
+		}
+
+		// Many indexers only report the size as an attribute or the enclosure length
+		if result.Size <= 0 {
+			result.Size, _ = strconv.ParseInt(item.attr("size"), 10, 64)
+		}
+		if result.Size <= 0 {
+			result.Size = item.Enclosure.Length
+		}
+		if seeders, err := strconv.Atoi(item.attr("seeders")); err == nil {
+			result.Seeders = &seeders
+		}
+		for _, category := range item.Categories {
+			if id, err := strconv.Atoi(category); err == nil {
+				result.Category = append(result.Category, id)
+			}
+		}
+
+		results = append(results, result)
+	}
+	return results, nil
+}
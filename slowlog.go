@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"stremfy/stream"
+	"strings"
+	"time"
+)
+
+// requestPhases measures the time a stream request spends in each upstream
+type requestPhases struct {
+	start     time.Time
+	names     []string
+	durations map[string]time.Duration
+}
+
+func newRequestPhases() *requestPhases {
+	return &requestPhases{start: time.Now(), durations: make(map[string]time.Duration)}
+}
+
+// track starts timing a phase, the returned func stops it. A phase timed twice adds up.
+func (p *requestPhases) track(name string) func() {
+	start := time.Now()
+	return func() {
+		if _, exists := p.durations[name]; !exists {
+			p.names = append(p.names, name)
+		}
+		p.durations[name] += time.Since(start)
+	}
+}
+
+// slowRequestEntry is the structured log line of a slow stream request
+type slowRequestEntry struct {
+	Time       string           `json:"time"`
+	Level      string           `json:"level"`
+	Msg        string           `json:"msg"`
+	Request    string           `json:"request"`
+	DurationMs int64            `json:"duration_ms"`
+	PhasesMs   map[string]int64 `json:"phases_ms"`
+}
+
+// logSlowRequest warns when a stream request took longer than the configured threshold
+func (ta *TorBoxStremioAddon) logSlowRequest(req stream.StreamRequest, phases *requestPhases) {
+	elapsed := time.Since(phases.start)
+	if ta.config.SlowRequestThreshold <= 0 || elapsed < ta.config.SlowRequestThreshold {
+		return
+	}
+
+	if ta.config.LogFormat == "json" {
+		entry := slowRequestEntry{
+			Time:       phases.start.UTC().Format(time.RFC3339Nano),
+			Level:      "warn",
+			Msg:        "slow request",
+			Request:    req.String(),
+			DurationMs: elapsed.Milliseconds(),
+			PhasesMs:   make(map[string]int64, len(phases.names)),
+		}
+		for _, name := range phases.names {
+			entry.PhasesMs[name] = phases.durations[name].Milliseconds()
+		}
+		json.NewEncoder(os.Stdout).Encode(entry)
+		return
+	}
+
+	parts := make([]string, len(phases.names))
+	for i, name := range phases.names {
+		parts[i] = fmt.Sprintf("%s %.1fs", name, phases.durations[name].Seconds())
+	}
+	log.Printf("🐢 Slow request %s: %.1fs (%s)", req.String(), elapsed.Seconds(), strings.Join(parts, ", "))
+}
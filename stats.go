@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync/atomic"
+)
+
+// requestCounter tracks the stream requests in flight, shedding those above a limit
+type requestCounter struct {
+	active atomic.Int64
+	shed   atomic.Int64
+	limit  int64 // 0 = unlimited
+}
+
+// acquire counts a request in flight, returning false (uncounted) when it must be shed
+func (rc *requestCounter) acquire() bool {
+	if n := rc.active.Add(1); rc.limit > 0 && n > rc.limit {
+		rc.active.Add(-1)
+		rc.shed.Add(1)
+		log.Printf("🚦 Shedding stream request: %d in flight (limit %d)", n-1, rc.limit)
+		return false
+	}
+	return true
+}
+
+func (rc *requestCounter) release() {
+	rc.active.Add(-1)
+}
+
+// handleStats serves GET /stats with the requests in flight and the cache and prefetch sizes.
+// With ?verbose=true and the admin token the cached metadata entries are listed too.
+func (ta *TorBoxStremioAddon) handleStats(w http.ResponseWriter, r *http.Request) {
+	verbose := r.URL.Query().Get("verbose") == "true" && ta.refreshAllowed(r.Header.Get("X-Admin-Token"))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"active_stream_requests": ta.streamRequests.active.Load(),
+		"shed_stream_requests":   ta.streamRequests.shed.Load(),
+		"max_stream_requests":    ta.streamRequests.limit,
+		"cache_entries":          int64(ta.cache.Size()),
+		"prefetch_queue":         int64(ta.backgroundWorker.GetQueueSize()),
+		"metadata_cache":         ta.metadataProvider.GetCacheStats(verbose),
+	})
+}
@@ -15,7 +15,7 @@ type Manifest struct {
 	Version       string         `json:"version"`
 	Name          string         `json:"name"`
 	Description   string         `json:"description"`
-	Resources     []string       `json:"resources"`
+	Resources     []Resource     `json:"resources"`
 	Types         []string       `json:"types"`
 	Catalogs      []Catalog      `json:"catalogs,omitempty"`
 	IDPrefixes    []string       `json:"idPrefixes,omitempty"`
@@ -25,6 +25,25 @@ type Manifest struct {
 	BehaviorHints *BehaviorHints `json:"behaviorHints,omitempty"`
 }
 
+// Resource is a manifest resource, optionally limited to some types and ID prefixes
+type Resource struct {
+	Name       string
+	Types      []string
+	IDPrefixes []string
+}
+
+// MarshalJSON encodes an unrestricted resource as its bare name, as Stremio expects
+func (r Resource) MarshalJSON() ([]byte, error) {
+	if len(r.Types) == 0 && len(r.IDPrefixes) == 0 {
+		return json.Marshal(r.Name)
+	}
+	return json.Marshal(struct {
+		Name       string   `json:"name"`
+		Types      []string `json:"types,omitempty"`
+		IDPrefixes []string `json:"idPrefixes,omitempty"`
+	}{r.Name, r.Types, r.IDPrefixes})
+}
+
 // BehaviorHints provides hints about addon behavior
 type BehaviorHints struct {
 	Adult                 bool `json:"adult,omitempty"`
@@ -113,6 +132,10 @@ type Stream struct {
 
 	// Metadata
 	BehaviorHints *StreamBehaviorHints `json:"behaviorHints,omitempty"`
+
+	// Sort keys set when the stream is built, never sent to Stremio
+	Seeders int    `json:"-"`
+	Quality string `json:"-"`
 }
 
 // StreamBehaviorHints provides hints for streams
@@ -146,6 +169,24 @@ type StreamRequest struct {
 	ID      string // IMDb ID
 	Season  int    // for series
 	Episode int    // for series
+
+	Refresh    bool   // ?refresh=true asks to bypass all caches
+	AdminToken string // X-Admin-Token header, required to honor Refresh
+	Sort       string // ?sort=size|seeders|quality overrides the configured sort, validated by the addon
+
+	AbsoluteEpisode int // anime-style absolute episode number, set by the addon when enabled
+}
+
+// ResultCounter is implemented by response writers that want the number of items in a response
+type ResultCounter interface {
+	SetResultCount(n int)
+}
+
+// setResultCount reports the number of items in a response to w if it is a ResultCounter
+func setResultCount(w http.ResponseWriter, n int) {
+	if counter, ok := w.(ResultCounter); ok {
+		counter.SetResultCount(n)
+	}
 }
 
 // Addon represents a Stremio addon
@@ -163,6 +204,11 @@ func NewAddon(manifest Manifest) *Addon {
 	}
 }
 
+// Manifest returns the addon manifest
+func (a *Addon) Manifest() Manifest {
+	return a.manifest
+}
+
 // SetCatalogHandler sets the catalog handler
 func (a *Addon) SetCatalogHandler(handler func(catalogType, catalogID string, extra map[string]string) (*CatalogResponse, error)) {
 	a.catalogHandler = handler
@@ -258,6 +304,7 @@ func (a *Addon) handleCatalog(w http.ResponseWriter, r *http.Request, parts []st
 		return
 	}
 
+	setResultCount(w, len(response.Metas))
 	json.NewEncoder(w).Encode(response)
 }
 
@@ -291,14 +338,28 @@ func (a *Addon) handleStream(w http.ResponseWriter, r *http.Request, parts []str
 	idPart := strings.TrimSuffix(parts[2], ".json")
 
 	req := StreamRequest{
-		Type: streamType,
+		Type:       streamType,
+		Refresh:    r.URL.Query().Get("refresh") == "true",
+		AdminToken: r.Header.Get("X-Admin-Token"),
+		Sort:       strings.ToLower(r.URL.Query().Get("sort")),
 	}
 
 	// Parse ID (format: imdb_id or imdb_id:season:episode)
 	idParts := strings.Split(idPart, ":")
 	req.ID = idParts[0]
+	if !req.IsMovie() && !req.IsSeries() {
+		// Addon-specific types carry their own prefixed IDs, e.g. stremfy-cloud:<hash>
+		req.ID = idPart
+	}
 
-	if len(idParts) >= 3 {
+	// Movies are a bare ID and series need season and episode, anything else is malformed
+	if !validStreamIDParts(req, idParts) {
+		setResultCount(w, 0)
+		json.NewEncoder(w).Encode(StreamResponse{Streams: []Stream{}})
+		return
+	}
+
+	if len(idParts) == 3 {
 		season, err := strconv.Atoi(idParts[1])
 		if err != nil {
 			http.Error(w, "Invalid season", http.StatusBadRequest)
@@ -319,9 +380,24 @@ func (a *Addon) handleStream(w http.ResponseWriter, r *http.Request, parts []str
 		return
 	}
 
+	setResultCount(w, len(response.Streams))
 	json.NewEncoder(w).Encode(response)
 }
 
+// validStreamIDParts checks the number of ID parts for the request type
+func validStreamIDParts(req StreamRequest, idParts []string) bool {
+	if idParts[0] == "" {
+		return false
+	}
+	switch {
+	case req.IsSeries():
+		return len(idParts) == 3
+	case req.IsMovie():
+		return len(idParts) == 1
+	}
+	return true
+}
+
 // ParseStreamID is a helper to parse stream ID from various formats
 func ParseStreamID(id string) (imdbID string, season, episode int, err error) {
 	// Format: tt1234567 or tt1234567:1: 1
@@ -0,0 +1,60 @@
+package stream
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStreamIDValidation(t *testing.T) {
+	var got []StreamRequest
+	addon := NewAddon(Manifest{Name: "test"})
+	addon.SetStreamHandler(func(req StreamRequest) (*StreamResponse, error) {
+		got = append(got, req)
+		return &StreamResponse{Streams: []Stream{{Name: "stream"}}}, nil
+	})
+
+	tests := []struct {
+		path string
+		want *StreamRequest // nil when the handler must not be called
+	}{
+		{"/stream/movie/tt0111161.json", &StreamRequest{Type: "movie", ID: "tt0111161"}},
+		{"/stream/series/tt0944947:1:5.json", &StreamRequest{Type: "series", ID: "tt0944947", Season: 1, Episode: 5}},
+		{"/stream/other/stremfy-cloud:abc123.json", &StreamRequest{Type: "other", ID: "stremfy-cloud:abc123"}},
+		// Malformed shapes get an empty response instead of being read as another type
+		{"/stream/series/tt0944947.json", nil},
+		{"/stream/series/tt0944947:1.json", nil},
+		{"/stream/series/tt0944947:1:5:2.json", nil},
+		{"/stream/movie/tt0111161:1:5.json", nil},
+		{"/stream/movie/tt0111161:1.json", nil},
+		{"/stream/movie/.json", nil},
+		{"/stream/series/:1:5.json", nil},
+	}
+	for _, tt := range tests {
+		got = nil
+		rec := httptest.NewRecorder()
+		addon.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: status = %d, want 200", tt.path, rec.Code)
+			continue
+		}
+		if tt.want == nil {
+			if len(got) != 0 || strings.TrimSpace(rec.Body.String()) != `{"streams":[]}` {
+				t.Errorf("%s: handler called with %+v, body %s, want an empty response", tt.path, got, rec.Body)
+			}
+			continue
+		}
+		if len(got) != 1 || got[0] != *tt.want {
+			t.Errorf("%s: handler called with %+v, want %+v", tt.path, got, *tt.want)
+		}
+	}
+
+	// A series ID with non-numeric season or episode is a bad request
+	rec := httptest.NewRecorder()
+	addon.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stream/series/tt0944947:one:5.json", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("non-numeric season: status = %d, want 400", rec.Code)
+	}
+}
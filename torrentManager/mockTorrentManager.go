@@ -7,7 +7,6 @@ import (
 	"log"
 	"net/http"
 	"path/filepath"
-	"regexp"
 	"stremfy/scrapers"
 	"strings"
 	"time"
@@ -15,13 +14,36 @@ import (
 	"github.com/IncSW/go-bencode"
 )
 
+// Download limits applied when not configured
+const (
+	DefaultDownloadTimeout = 10 * time.Second
+	DefaultMaxTorrentBytes = 10 << 20 // 10 MB, real .torrent files are far smaller
+)
+
+// DownloadConfig limits .torrent downloads, whose links come from untrusted indexers
+type DownloadConfig struct {
+	// Timeout bounds a whole download (default 10 seconds)
+	Timeout time.Duration
+	// MaxBytes rejects larger responses (default 10 MB)
+	MaxBytes int64
+}
+
 type MockTorrentManager struct {
-	client *http.Client
+	client   *http.Client
+	maxBytes int64
 }
 
-func NewMockTorrentManager() *MockTorrentManager {
+func NewMockTorrentManager(config DownloadConfig) *MockTorrentManager {
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultDownloadTimeout
+	}
+	if config.MaxBytes <= 0 {
+		config.MaxBytes = DefaultMaxTorrentBytes
+	}
+
 	return &MockTorrentManager{
-		client: &http.Client{Timeout: 10 * time.Second},
+		client:   &http.Client{Timeout: config.Timeout},
+		maxBytes: config.MaxBytes,
 	}
 }
 
@@ -56,11 +78,24 @@ func (m *MockTorrentManager) DownloadTorrent(ctx context.Context, url string) ([
 		return nil, hash, magnetURL, nil
 	}
 
-	// Read torrent file content
-	content, err := io.ReadAll(resp.Body)
+	if resp.ContentLength > m.maxBytes {
+		return nil, "", "", fmt.Errorf("torrent file too large: %d bytes (max %d)", resp.ContentLength, m.maxBytes)
+	}
+
+	// Read torrent file content, one byte past the limit to detect oversized bodies
+	content, err := io.ReadAll(io.LimitReader(resp.Body, m.maxBytes+1))
 	if err != nil {
 		return nil, "", "", err
 	}
+	if int64(len(content)) > m.maxBytes {
+		return nil, "", "", fmt.Errorf("torrent file too large: more than %d bytes", m.maxBytes)
+	}
+
+	// Indexers often answer with an HTML error page instead of the torrent file.
+	// Some send torrents as octet-stream, so the bencode dictionary prefix is what decides.
+	if len(content) == 0 || content[0] != 'd' {
+		return nil, "", "", fmt.Errorf("response is not a torrent file (content type %q)", resp.Header.Get("Content-Type"))
+	}
 
 	return content, "", "", nil
 }
@@ -223,12 +258,7 @@ func (m *MockTorrentManager) GetCachedTorrentFiles(ctx context.Context, hash str
 }
 
 func extractHashFromMagnet(magnetURL string) string {
-	// Extract info hash from magnet link
+	// Extract info hash from magnet link, hex or base32
 	// Format: magnet:?xt=urn:btih: HASH&...
-	re := regexp.MustCompile(`xt=urn:btih:([a-fA-F0-9]{40})`)
-	matches := re.FindStringSubmatch(magnetURL)
-	if len(matches) > 1 {
-		return strings.ToLower(matches[1])
-	}
-	return ""
+	return scrapers.HashFromMagnet(magnetURL)
 }
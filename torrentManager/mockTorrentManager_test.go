@@ -0,0 +1,62 @@
+package torrentManager
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDownloadTorrentLimits(t *testing.T) {
+	const torrent = "d8:announce3:url4:infod4:name4:testee"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/small.torrent":
+			w.Write([]byte(torrent))
+		case "/huge.torrent":
+			w.Write([]byte("d" + strings.Repeat("x", 4096)))
+		case "/chunked.torrent":
+			// Flushing first drops the Content-Length, so only the read limit catches it
+			w.Write([]byte("d"))
+			w.(http.Flusher).Flush()
+			for range 8 {
+				w.Write([]byte(strings.Repeat("x", 512)))
+			}
+		case "/slow.torrent":
+			time.Sleep(200 * time.Millisecond)
+			w.Write([]byte(torrent))
+		}
+	}))
+	defer server.Close()
+
+	m := NewMockTorrentManager(DownloadConfig{Timeout: 50 * time.Millisecond, MaxBytes: 1024})
+
+	content, _, _, err := m.DownloadTorrent(context.Background(), server.URL+"/small.torrent")
+	if err != nil || string(content) != torrent {
+		t.Errorf("small torrent = %q, %v, want its content", content, err)
+	}
+
+	for _, path := range []string{"/huge.torrent", "/chunked.torrent"} {
+		content, _, _, err := m.DownloadTorrent(context.Background(), server.URL+path)
+		if err == nil || !strings.Contains(err.Error(), "too large") {
+			t.Errorf("%s: got %d bytes, error %v, want a too large error", path, len(content), err)
+		}
+	}
+
+	if _, _, _, err := m.DownloadTorrent(context.Background(), server.URL+"/slow.torrent"); err == nil {
+		t.Error("slow torrent downloaded, want a timeout error")
+	}
+}
+
+func TestDownloadConfigDefaults(t *testing.T) {
+	m := NewMockTorrentManager(DownloadConfig{})
+	if m.maxBytes != DefaultMaxTorrentBytes {
+		t.Errorf("maxBytes = %d, want %d", m.maxBytes, DefaultMaxTorrentBytes)
+	}
+	if m.client.Timeout != DefaultDownloadTimeout {
+		t.Errorf("timeout = %v, want %v", m.client.Timeout, DefaultDownloadTimeout)
+	}
+}
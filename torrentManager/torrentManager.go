@@ -7,15 +7,15 @@ import (
 	"stremfy/scrapers"
 )
 
-// TorrentManager wraps TorBox client and provides torrent management functionality
+// TorrentManager wraps the debrid provider and provides torrent management functionality
 type TorrentManager struct {
-	torboxClient *debrid.Client
+	torboxClient debrid.Provider
 	mock         *MockTorrentManager
 }
 
-// NewTorrentManager creates a new TorrentManager with TorBox integration
-func NewTorrentManager(torboxClient *debrid.Client) *TorrentManager {
-	m := NewMockTorrentManager()
+// NewTorrentManager creates a new TorrentManager with debrid integration
+func NewTorrentManager(torboxClient debrid.Provider, config DownloadConfig) *TorrentManager {
+	m := NewMockTorrentManager(config)
 	return &TorrentManager{
 		torboxClient: torboxClient,
 		mock:         m,
@@ -38,13 +38,13 @@ func (t *TorrentManager) ExtractTrackersFromMagnet(magnetURL string) []string {
 	return t.mock.ExtractTrackersFromMagnet(magnetURL)
 }
 
-func (t *TorrentManager) GetCachedTorrentFiles(hash string) ([]scrapers.TorrentFile, bool, error) {
+func (t *TorrentManager) GetCachedTorrentFiles(ctx context.Context, hash string) ([]scrapers.TorrentFile, bool, error) {
 	if t.torboxClient == nil {
 		return nil, false, fmt.Errorf("torbox client not initialized")
 	}
 
 	// Check if the torrent is cached
-	cacheResults, err := t.torboxClient.CheckCacheSingle(hash)
+	cacheResults, err := t.torboxClient.CheckCacheSingle(ctx, hash)
 	if err != nil {
 		return nil, false, fmt.Errorf("failed to check cache: %w", err)
 	}
@@ -71,3 +71,8 @@ func (t *TorrentManager) GetCachedTorrentFiles(hash string) ([]scrapers.TorrentF
 
 	return torrentFiles, true, nil
 }
+
+var (
+	_ scrapers.TorrentManager = (*TorrentManager)(nil)
+	_ scrapers.TorrentManager = (*MockTorrentManager)(nil)
+)
@@ -2,6 +2,7 @@ package types
 
 import (
 	"context"
+	"encoding/gob"
 	"time"
 )
 
@@ -12,6 +13,23 @@ type ScrapeRequest struct {
 	Season      int
 	Episode     *int
 	MediaOnlyID string
+
+	// AbsoluteEpisode is the anime-style absolute number of Episode, 0 when not used
+	AbsoluteEpisode int
+
+	// AltTitles are other titles searched and matched too, e.g. the original-language title
+	AltTitles []string
+
+	// Year also searches movies as "<title> <year>", to find remakes tagged with their year
+	Year string
+
+	// PacksOnly drops single-episode results before they are processed
+	PacksOnly bool
+}
+
+// Titles returns the title and the alternative titles of the request
+func (r ScrapeRequest) Titles() []string {
+	return append([]string{r.Title}, r.AltTitles...)
 }
 
 // ScrapeResult represents a processed torrent result
@@ -28,6 +46,28 @@ type ScrapeResult struct {
 // SearchFunc is a function type for searching torrents
 type SearchFunc func(ctx context.Context, req ScrapeRequest) ([]ScrapeResult, error)
 
+type skipCacheKey struct{}
+
+// WithSkipCache returns a context that makes lookups bypass their caches and fetch fresh data
+func WithSkipCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipCacheKey{}, true)
+}
+
+// SkipCache reports whether lookups bound to ctx should bypass their caches
+func SkipCache(ctx context.Context) bool {
+	skip, _ := ctx.Value(skipCacheKey{}).(bool)
+	return skip
+}
+
+// CheckCacheFunc returns which of the given hashes are cached on the debrid service
+type CheckCacheFunc func(hashes []string) ([]string, error)
+
+// Register the cached results, so the persisted cache decodes them
+func init() {
+	gob.Register(ScrapeResult{})
+	gob.Register([]ScrapeResult{})
+}
+
 // Cache interface for cache operations
 type Cache interface {
 	Get(key string) (interface{}, bool)
@@ -37,3 +77,19 @@ type Cache interface {
 	Clear()
 	Size() int
 }
+
+// CacheGet returns the cached value of key if it is a T. Store values of types registered
+// with gob in the init of their package (see caching/cache.go), or they come back as a
+// different type after a restart.
+func CacheGet[T any](c Cache, key string) (T, bool) {
+	var zero T
+	cached, found := c.Get(key)
+	if !found {
+		return zero, false
+	}
+	value, ok := cached.(T)
+	if !ok {
+		return zero, false
+	}
+	return value, true
+}
@@ -0,0 +1,19 @@
+package types
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestScrapeRequestTitles(t *testing.T) {
+	request := ScrapeRequest{Title: "A Viagem de Chihiro", AltTitles: []string{"Spirited Away", "Sen to Chihiro no Kamikakushi"}}
+	want := []string{"A Viagem de Chihiro", "Spirited Away", "Sen to Chihiro no Kamikakushi"}
+	if got := request.Titles(); !slices.Equal(got, want) {
+		t.Errorf("Titles() = %q, want %q", got, want)
+	}
+
+	if got := (ScrapeRequest{Title: "Inception"}).Titles(); !slices.Equal(got, []string{"Inception"}) {
+		t.Errorf("Titles() without alternatives = %q, want only the title", got)
+	}
+
+}
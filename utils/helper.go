@@ -1,21 +1,67 @@
 package utils
 
-import "strings"
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
 
-func ExtractQuality(title string) string {
-	titleLower := strings.ToLower(title)
+// keywordLabel maps the keywords found in release titles to a label
+type keywordLabel struct {
+	keywords []string
+	label    string
+}
 
-	qualities := []struct {
-		keywords []string
-		label    string
-	}{
+var (
+	qualityKeywords = []keywordLabel{
 		{[]string{"2160p", "4k", "uhd"}, "4K"},
 		{[]string{"1080p", "fhd"}, "1080p"},
 		{[]string{"720p", "hd"}, "720p"},
 		{[]string{"480p"}, "480p"},
 	}
+	codecKeywords = []keywordLabel{
+		{[]string{"h265", "hevc", "x265"}, "H265"},
+		{[]string{"h264", "x264", "avc"}, "H264"},
+		{[]string{"av1"}, "AV1"},
+		{[]string{"xvid"}, "XviD"},
+	}
+	sourceKeywords = []keywordLabel{
+		{[]string{"bluray", "blu-ray", "bdrip", "bd-rip", "brrip", "br-rip"}, "Source"},
+		{[]string{"webdl", "web-dl", "dvdrip", "dvd-rip", "webrip", "web-rip", "dvd"}, "Premium"},
+		{[]string{"screener", "scr", "tvrip", "tv-rip", "hdtv", "pdtv"}, "Standard"},
+		{[]string{"cam", "camrip", "cam-rip", "telesync", "ts", "workprint", "wp"}, "Poor"},
+	}
+)
+
+// shortKeywords are keywords that are also ordinary title words, e.g. "Cam" (2018), so they
+// aren't treated as release noise
+var shortKeywords = map[string]bool{"hd": true, "ts": true, "cam": true, "dvd": true}
+
+// releaseNoise holds the whole quality, codec and source keywords as words, e.g. "1080p" and
+// "webdl" for "web-dl", but not their fragments like "web" or "tv"
+var releaseNoise = func() map[string]bool {
+	noise := make(map[string]bool)
+	for _, list := range [][]keywordLabel{qualityKeywords, codecKeywords, sourceKeywords} {
+		for _, kl := range list {
+			for _, kw := range kl.keywords {
+				if !shortKeywords[kw] {
+					noise[strings.ReplaceAll(kw, "-", "")] = true
+				}
+			}
+		}
+	}
+	return noise
+}()
 
-	for _, q := range qualities {
+// IsReleaseNoise reports whether a lowercase word is a quality, codec or source tag, e.g. "x265"
+func IsReleaseNoise(word string) bool {
+	return releaseNoise[word]
+}
+
+func ExtractQuality(title string) string {
+	titleLower := strings.ToLower(title)
+
+	for _, q := range qualityKeywords {
 		for _, kw := range q.keywords {
 			if strings.Contains(titleLower, kw) {
 				return q.label
@@ -26,20 +72,20 @@ func ExtractQuality(title string) string {
 	return "Unknown"
 }
 
+// QualityRank orders quality labels from ExtractQuality, higher is better and "Unknown" is 0
+func QualityRank(label string) int {
+	for i, q := range qualityKeywords {
+		if q.label == label {
+			return len(qualityKeywords) - i
+		}
+	}
+	return 0
+}
+
 func ExtractCodec(title string) string {
 	titleLower := strings.ToLower(title)
 
-	codecs := []struct {
-		keywords []string
-		label    string
-	}{
-		{[]string{"h265", "hevc", "x265"}, "H265"},
-		{[]string{"h264", "x264", "avc"}, "H264"},
-		{[]string{"av1"}, "AV1"},
-		{[]string{"xvid"}, "XviD"},
-	}
-
-	for _, c := range codecs {
+	for _, c := range codecKeywords {
 		for _, kw := range c.keywords {
 			if strings.Contains(titleLower, kw) {
 				return c.label
@@ -53,17 +99,7 @@ func ExtractCodec(title string) string {
 func ExtractSource(title string) string {
 	titleLower := strings.ToLower(title)
 
-	codecs := []struct {
-		keywords []string
-		label    string
-	}{
-		{[]string{"bluray", "blu-ray", "bdrip", "bd-rip", "brrip", "br-rip"}, "Source"},
-		{[]string{"webdl", "web-dl", "dvdrip", "dvd-rip", "webrip", "web-rip", "dvd"}, "Premium"},
-		{[]string{"screener", "scr", "tvrip", "tv-rip", "hdtv", "pdtv"}, "Standard"},
-		{[]string{"cam", "camrip", "cam-rip", "telesync", "ts", "workprint", "wp"}, "Poor"},
-	}
-
-	for _, c := range codecs {
+	for _, c := range sourceKeywords {
 		for _, kw := range c.keywords {
 			if strings.Contains(titleLower, kw) {
 				return c.label
@@ -73,3 +109,105 @@ func ExtractSource(title string) string {
 
 	return ""
 }
+
+// Audio labels returned by ExtractAudio
+const (
+	AudioDual   = "Dual Audio"
+	AudioDubbed = "Dubbed"
+	AudioSubbed = "Subbed"
+)
+
+var (
+	// A bare "DUAL" only counts as the uppercase release tag, so titles like "Dual Survival" don't match
+	dualAudioPattern = regexp.MustCompile(`(?:^|[^\pL])(?:(?i:dual[\s._-]*[áa]udio)|DUAL)(?:[^\pL]|$)`)
+	dubbedPattern    = regexp.MustCompile(`(?i)(?:^|[^\pL])(?:dublado|dubbed|dub|nacional)(?:[^\pL]|$)`)
+	subbedPattern    = regexp.MustCompile(`(?i)(?:^|[^\pL])(?:legendado|legendas?|subbed)(?:[^\pL]|$)`)
+)
+
+// ExtractAudio tells dual-audio (original + dub), dubbed-only and subtitled releases apart,
+// e.g. "Dual Áudio" -> AudioDual, "Dublado" -> AudioDubbed, "Legendado" -> AudioSubbed
+func ExtractAudio(title string) string {
+	switch {
+	case dualAudioPattern.MatchString(title):
+		return AudioDual
+	case dubbedPattern.MatchString(title):
+		return AudioDubbed
+	case subbedPattern.MatchString(title):
+		return AudioSubbed
+	}
+	return ""
+}
+
+// EstimateBitrate returns the approximate bitrate in Mbps of a file played over runtimeMinutes,
+// or 0 when either value is unknown
+func EstimateBitrate(size int64, runtimeMinutes int) float64 {
+	if size <= 0 || runtimeMinutes <= 0 {
+		return 0
+	}
+	return float64(size) * 8 / float64(runtimeMinutes*60) / 1e6
+}
+
+var (
+	trailingTagPattern  = regexp.MustCompile(`\s*[\[(][^\])]*[\])]\s*$`)
+	leadingGroupPattern = regexp.MustCompile(`^\[([^\]]+)\]`)
+	groupPattern        = regexp.MustCompile(`-([A-Za-z0-9]+)$`)
+)
+
+// ExtractReleaseGroup returns the release group of a title ("...x264-GROUP" or "[Group] ..."), or ""
+func ExtractReleaseGroup(title string) string {
+	name := strings.TrimSpace(title)
+
+	// Drop a known video extension
+	if ext := filepath.Ext(name); len(ext) > 1 && len(ext) <= 5 && !strings.ContainsAny(ext, " -") {
+		name = strings.TrimSuffix(name, ext)
+	}
+
+	// Strip trailing tags like "[rartv]" or "(1080p)"
+	for trailingTagPattern.MatchString(name) {
+		name = trailingTagPattern.ReplaceAllString(name, "")
+	}
+
+	if matches := groupPattern.FindStringSubmatch(name); len(matches) == 2 {
+		// "WEB-DL" is a source, not a group
+		if !strings.EqualFold(matches[1], "dl") {
+			return matches[1]
+		}
+	}
+
+	if matches := leadingGroupPattern.FindStringSubmatch(strings.TrimSpace(title)); len(matches) == 2 {
+		return strings.TrimSpace(matches[1])
+	}
+
+	return ""
+}
+
+var (
+	nameYearPattern   = regexp.MustCompile(`\b(19\d{2}|20\d{2})\b`)
+	nameCutoffPattern = regexp.MustCompile(`(?i)\b(s\d{1,2}(e\d{1,3})?|\d{1,2}x\d{2,3}|season|temporada|complete|2160p|1080p|720p|480p|4k|uhd|bluray|blu-ray|web-?dl|webrip|hdtv|dvdrip|x26[45]|h\.?26[45]|hevc)\b| - \d{1,4}\b`)
+)
+
+// ParseTorrentName extracts a searchable title and year from a release name,
+// e.g. "The.Matrix.1999.1080p.BluRay.x264-GROUP" -> ("The Matrix", "1999")
+func ParseTorrentName(name string) (title, year string) {
+	name = strings.TrimSpace(leadingGroupPattern.ReplaceAllString(strings.TrimSpace(name), ""))
+	if ext := filepath.Ext(name); len(ext) > 1 && len(ext) <= 5 && !strings.ContainsAny(ext, " -") {
+		name = strings.TrimSuffix(name, ext)
+	}
+	name = strings.NewReplacer(".", " ", "_", " ").Replace(name)
+
+	cut := len(name)
+	if loc := nameCutoffPattern.FindStringIndex(name); loc != nil && loc[0] > 0 {
+		cut = loc[0]
+	}
+
+	// The release year is the last year before the cutoff ("Blade Runner 2049 2017")
+	for _, loc := range nameYearPattern.FindAllStringIndex(name[:cut], -1) {
+		if loc[0] > 0 {
+			year = name[loc[0]:loc[1]]
+			cut = loc[0]
+		}
+	}
+
+	title = strings.Trim(name[:cut], " -([")
+	return strings.Join(strings.Fields(title), " "), year
+}
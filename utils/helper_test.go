@@ -0,0 +1,52 @@
+package utils
+
+import "testing"
+
+func TestExtractAudio(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Movie.2020.1080p.WEB-DL.DUAL.x264", AudioDual},
+		{"Filme 2020 1080p Dual Áudio", AudioDual},
+		{"Filme.2020.1080p.DUAL-AUDIO.x265", AudioDual},
+		{"Movie 2020 dual audio 720p", AudioDual},
+		{"Dual Survival S01E01 720p HDTV", ""},
+		{"Dual.Survival.S02.1080p.WEB", ""},
+		{"Dual Survival S01 Dublado 720p", AudioDubbed},
+		{"Filme 2020 1080p Legendado", AudioSubbed},
+	}
+	for _, tt := range tests {
+		if got := ExtractAudio(tt.title); got != tt.want {
+			t.Errorf("ExtractAudio(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestIsReleaseNoise(t *testing.T) {
+	tests := []struct {
+		word string
+		want bool
+	}{
+		{"1080p", true},
+		{"x265", true},
+		{"webdl", true},
+		{"bluray", true},
+		{"hdtv", true},
+		// Fragments of split keywords and short keywords are title words too
+		{"web", false},
+		{"dl", false},
+		{"tv", false},
+		{"rip", false},
+		{"hd", false},
+		{"ts", false},
+		{"cam", false},
+		{"dvd", false},
+		{"chihiro", false},
+	}
+	for _, tt := range tests {
+		if got := IsReleaseNoise(tt.word); got != tt.want {
+			t.Errorf("IsReleaseNoise(%q) = %v, want %v", tt.word, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTrackers are reliable public trackers injected into P2P streams
+var DefaultTrackers = []string{
+	"udp://tracker.opentrackr.org:1337/announce",
+	"udp://open.demonii.com:1337/announce",
+	"udp://open.stealth.si:80/announce",
+	"udp://tracker.torrent.eu.org:451/announce",
+	"udp://exodus.desync.com:6969/announce",
+	"udp://tracker.openbittorrent.com:6969/announce",
+	"udp://explodie.org:6969/announce",
+	"udp://tracker.tiny-vps.com:6969/announce",
+}
+
+// MergeTrackers concatenates tracker lists, dropping blanks and duplicates while keeping order
+func MergeTrackers(lists ...[]string) []string {
+	seen := make(map[string]bool)
+	var merged []string
+
+	for _, list := range lists {
+		for _, tracker := range list {
+			tracker = strings.TrimSpace(tracker)
+			key := strings.TrimSuffix(strings.ToLower(tracker), "/")
+			if tracker == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			merged = append(merged, tracker)
+		}
+	}
+
+	return merged
+}
+
+// TrackerList holds the default trackers, optionally kept current from a remote list
+// such as ngosang/trackerslist. It falls back to the last good (or built-in) list on errors.
+type TrackerList struct {
+	mu       sync.RWMutex
+	trackers []string
+	client   *http.Client
+}
+
+// NewTrackerList creates a tracker list starting with the built-in trackers
+func NewTrackerList(builtin []string) *TrackerList {
+	return &TrackerList{
+		trackers: builtin,
+		client:   &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// Get returns the current trackers
+func (tl *TrackerList) Get() []string {
+	tl.mu.RLock()
+	defer tl.mu.RUnlock()
+	return tl.trackers
+}
+
+// Refresh replaces the trackers with the list at url (one tracker per line)
+func (tl *TrackerList) Refresh(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := tl.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var trackers []string
+	scanner := bufio.NewScanner(io.LimitReader(resp.Body, 1<<20))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if isTrackerURL(line) {
+			trackers = append(trackers, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read tracker list: %w", err)
+	}
+	if len(trackers) == 0 {
+		return fmt.Errorf("tracker list is empty")
+	}
+
+	tl.mu.Lock()
+	tl.trackers = MergeTrackers(trackers)
+	tl.mu.Unlock()
+	return nil
+}
+
+// StartRefresh loads the list at url now and then every interval until ctx is done,
+// keeping the current trackers whenever a refresh fails
+func (tl *TrackerList) StartRefresh(ctx context.Context, url string, interval time.Duration) {
+	refresh := func() {
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+
+		if err := tl.Refresh(ctx, url); err != nil {
+			log.Printf("⚠️  Failed to refresh trackers from %s, keeping %d current: %v", url, len(tl.Get()), err)
+			return
+		}
+		log.Printf("📡 Loaded %d trackers from %s", len(tl.Get()), url)
+	}
+
+	go func() {
+		refresh()
+		if interval <= 0 {
+			return
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				refresh()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// isTrackerURL reports whether a line of a tracker list is an announce URL
+func isTrackerURL(line string) bool {
+	for _, scheme := range []string{"udp://", "http://", "https://", "ws://", "wss://"} {
+		if strings.HasPrefix(strings.ToLower(line), scheme) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStartRefreshStopsWithContext(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		fmt.Fprintln(w, "udp://tracker.example:1337/announce")
+	}))
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tl := NewTrackerList(nil)
+	tl.StartRefresh(ctx, server.URL, 5*time.Millisecond)
+
+	deadline := time.Now().Add(time.Second)
+	for hits.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if hits.Load() < 3 {
+		t.Fatalf("refreshed %d times in a second, want at least 3", hits.Load())
+	}
+	if got := tl.Get(); len(got) != 1 || got[0] != "udp://tracker.example:1337/announce" {
+		t.Errorf("Get() = %q, want the refreshed list", got)
+	}
+
+	cancel()
+	time.Sleep(20 * time.Millisecond) // let a refresh already running finish
+	stopped := hits.Load()
+	time.Sleep(50 * time.Millisecond)
+	if got := hits.Load(); got != stopped {
+		t.Errorf("refreshed %d more times after the context was cancelled", got-stopped)
+	}
+}
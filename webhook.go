@@ -0,0 +1,133 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// maxWebhookBody caps the size of a TorBox webhook payload
+const maxWebhookBody = 64 << 10
+
+// torboxWebhook is the notification TorBox posts when a download changes state
+type torboxWebhook struct {
+	Type string `json:"type"`
+	Data struct {
+		Title     string `json:"title"`
+		Message   string `json:"message"`
+		TorrentID int    `json:"torrent_id,omitempty"`
+	} `json:"data"`
+}
+
+// downloadWaiters tracks requests waiting for TorBox downloads to finish
+type downloadWaiters struct {
+	mu      sync.Mutex
+	waiters map[string][]chan struct{}
+}
+
+func newDownloadWaiters() *downloadWaiters {
+	return &downloadWaiters{waiters: make(map[string][]chan struct{})}
+}
+
+// wait registers interest in a torrent; the channel receives on every notification
+// that may concern it. Call the returned function to unregister.
+func (dw *downloadWaiters) wait(torrentID string) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	dw.mu.Lock()
+	dw.waiters[torrentID] = append(dw.waiters[torrentID], ch)
+	dw.mu.Unlock()
+
+	return ch, func() {
+		dw.mu.Lock()
+		defer dw.mu.Unlock()
+
+		chans := dw.waiters[torrentID]
+		for i, c := range chans {
+			if c == ch {
+				chans = append(chans[:i], chans[i+1:]...)
+				break
+			}
+		}
+		if len(chans) == 0 {
+			delete(dw.waiters, torrentID)
+		} else {
+			dw.waiters[torrentID] = chans
+		}
+	}
+}
+
+// notify wakes the waiters of a torrent, or every waiter when torrentID is empty
+func (dw *downloadWaiters) notify(torrentID string) int {
+	dw.mu.Lock()
+	defer dw.mu.Unlock()
+
+	woken := 0
+	for id, chans := range dw.waiters {
+		if torrentID != "" && id != torrentID {
+			continue
+		}
+		for _, ch := range chans {
+			select {
+			case ch <- struct{}{}:
+			default: // already pending
+			}
+			woken++
+		}
+	}
+	return woken
+}
+
+// webhookAuthorized checks the shared secret, sent as ?secret= (TorBox cannot set headers) or X-Webhook-Secret
+func (ta *TorBoxStremioAddon) webhookAuthorized(r *http.Request) bool {
+	secret := r.Header.Get("X-Webhook-Secret")
+	if secret == "" {
+		secret = r.URL.Query().Get("secret")
+	}
+	if ta.config.WebhookSecret == "" || secret == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(secret), []byte(ta.config.WebhookSecret)) == 1
+}
+
+// handleTorBoxWebhook receives TorBox download notifications and wakes the
+// requests waiting for the download. Notifications without a torrent ID wake
+// every waiter, which then re-checks its own torrent.
+func (ta *TorBoxStremioAddon) handleTorBoxWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !ta.webhookAuthorized(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody+1))
+	if err != nil || len(body) > maxWebhookBody {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	var payload torboxWebhook
+	if err := json.Unmarshal(body, &payload); err != nil || payload.Type == "" {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	torrentID := ""
+	if payload.Data.TorrentID > 0 {
+		torrentID = strconv.Itoa(payload.Data.TorrentID)
+	}
+
+	woken := ta.downloadWaiters.notify(torrentID)
+	log.Printf("🪝 TorBox webhook: %s (%s), woke %d waiting requests",
+		payload.Data.Title, strings.TrimSpace(payload.Data.Message), woken)
+
+	w.WriteHeader(http.StatusNoContent)
+}